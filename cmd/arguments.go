@@ -1,10 +1,27 @@
 package cmd
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+
+	"gopkg.in/yaml.v2"
 )
 
+var paramsFile string
+var paramsInput string
+
+var errNoArgument = errors.New("argument is not found")
+
 type arguments struct {
 	args []string
 }
@@ -24,10 +41,189 @@ func (a arguments) validate() error {
 
 func (a arguments) get(name string) (string, error) {
 	for _, arg := range a.args {
-		i := strings.Index(arg, name+"=")
-		if i > -1 {
-			return arg[i+len(name+"="):], nil
+		if strings.HasPrefix(arg, name+"=") {
+			return arg[len(name+"="):], nil
+		}
+	}
+	return "", errNoArgument
+}
+
+var envRef = regexp.MustCompile(`^(\\*)env:([A-Za-z_][A-Za-z0-9_]*)$`)
+
+// resolveValue 解析参数值中的引用: @path 读取文件内容, env:VAR 读取环境变量,
+// @@ 开头的值去掉一个 @ 后原样使用, \env:VAR 去掉一个 \ 后原样使用
+func resolveValue(val string) (string, error) {
+	switch {
+	case strings.HasPrefix(val, "@@"):
+		return val[1:], nil
+	case strings.HasPrefix(val, "@"):
+		bin, err := ioutil.ReadFile(val[1:])
+		if err != nil {
+			return "", err
+		}
+		return string(bin), nil
+	}
+	if m := envRef.FindStringSubmatch(val); m != nil {
+		if m[1] != "" {
+			return val[1:], nil
+		}
+		v, ok := os.LookupEnv(m[2])
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", m[2])
+		}
+		return v, nil
+	}
+	return val, nil
+}
+
+// escapeValue 转义会被 resolveValue 当作引用的值，resolveValue 会还原出原来的值
+func escapeValue(val string) string {
+	switch {
+	case strings.HasPrefix(val, "@"):
+		return "@" + val
+	case envRef.MatchString(val):
+		return `\` + val
+	}
+	return val
+}
+
+// readParams 读取 --params-file 指定的参数文件, "-" 表示从标准输入读取。
+// 格式按扩展名确定: .yaml/.yml, .json 或 .env, 标准输入的格式按内容判断
+func readParams(path string, stdin io.Reader) (map[string]string, error) {
+	var bin []byte
+	var err error
+	if path == "-" {
+		bin, err = ioutil.ReadAll(stdin)
+	} else {
+		bin, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var params map[string]string
+	switch paramsFormat(path, bin) {
+	case "json":
+		params, err = parseJSONParams(bin)
+	case "env":
+		params, err = parseEnvParams(bin)
+	default:
+		params, err = parseYAMLParams(bin)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read parameters from %s: %w", paramsSource(path), err)
+	}
+	return params, nil
+}
+
+func paramsSource(path string) string {
+	if path == "-" {
+		return "stdin"
+	}
+	return path
+}
+
+var envLine = regexp.MustCompile(`^\s*(export\s+)?[A-Za-z_][A-Za-z0-9_.]*\s*=`)
+
+func paramsFormat(path string, bin []byte) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return "json"
+	case ".env":
+		return "env"
+	case ".yaml", ".yml":
+		return "yaml"
+	}
+	if path != "-" && strings.HasSuffix(filepath.Base(path), ".env") {
+		return "env"
+	}
+	trimmed := bytes.TrimSpace(bin)
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		return "json"
+	}
+	for _, line := range strings.Split(string(trimmed), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !envLine.MatchString(line) {
+			return "yaml"
+		}
+	}
+	return "env"
+}
+
+// json 中的数字和布尔值也作为字符串提交，数字保持原来的写法，如 1000000 不会变成 1e+06
+func parseJSONParams(bin []byte) (map[string]string, error) {
+	raw := map[string]interface{}{}
+	decoder := json.NewDecoder(bytes.NewReader(bin))
+	decoder.UseNumber()
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, err
+	}
+	return scalarParams(raw)
+}
+
+// yaml 中的数字和布尔值按原文提交，如 1.10 不会变成 1.1
+func parseYAMLParams(bin []byte) (map[string]string, error) {
+	raw := map[string]interface{}{}
+	if err := yaml.Unmarshal(bin, &raw); err != nil {
+		return nil, err
+	}
+	if _, err := scalarParams(raw); err != nil {
+		return nil, err
+	}
+	// 解析为字符串时 yaml 保留标量的原文
+	params := map[string]string{}
+	if err := yaml.Unmarshal(bin, &params); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+func scalarParams(raw map[string]interface{}) (map[string]string, error) {
+	params := map[string]string{}
+	for name, val := range raw {
+		switch v := val.(type) {
+		case nil:
+			params[name] = ""
+		case string:
+			params[name] = v
+		case bool, int, int64, uint64, float64, json.Number:
+			params[name] = fmt.Sprint(v)
+		default:
+			return nil, fmt.Errorf("value of %s should be a string, a number or a boolean", name)
+		}
+	}
+	return params, nil
+}
+
+// parseEnvParams 解析 KEY=VAL 格式, 支持 export 前缀、注释以及单双引号
+func parseEnvParams(bin []byte) (map[string]string, error) {
+	params := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(bin))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+		i := strings.Index(line, "=")
+		if i < 1 {
+			return nil, fmt.Errorf("line %d should look as \"key=val\"", n)
+		}
+		name, val := strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+		switch {
+		case len(val) > 1 && val[0] == '"' && val[len(val)-1] == '"':
+			v, err := strconv.Unquote(val)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n, err)
+			}
+			val = v
+		case len(val) > 1 && val[0] == '\'' && val[len(val)-1] == '\'':
+			val = val[1 : len(val)-1]
 		}
+		params[name] = val
 	}
-	return "", fmt.Errorf("argument is not found")
+	return params, scanner.Err()
 }
@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj"
+	"github.com/spf13/cobra"
+)
+
+type artifactsOptions struct {
+	dir string
+	zip bool
+}
+
+func init() {
+	var opts artifactsOptions
+	artifactsCmd := &cobra.Command{
+		Use:   "artifacts JOB [BUILD|lastBuild]",
+		Short: "列出构建的制品",
+		Run: func(cmd *cobra.Command, args []string) {
+			env := mustInit(ENV)
+			job, err := selectJob(env, args[0])
+			check(err)
+			build := "lastBuild"
+			if len(args) > 1 {
+				build = args[1]
+			}
+			check(listArtifacts(env, job, build))
+		},
+		Args: cobra.RangeArgs(1, 2),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 1 {
+				if err := checkBuild(args[1]); err != nil {
+					return err
+				}
+			}
+			return preRunE(cmd, args)
+		},
+	}
+	getCmd := &cobra.Command{
+		Use:   "get JOB BUILD|lastBuild [PATTERN]",
+		Short: "下载构建的制品",
+		Long: `下载与 PATTERN 匹配的制品到 -d 指定的目录，制品的相对路径会被保留。
+PATTERN 是 shell 通配符，不含 / 时也与文件名匹配，例如 '*.jar'，省略时下载全部制品。
+同时下载的文件数与 Jenkins 的并发数相同，已存在的同样大小的文件会被跳过，
+中断的下载会从留下的 .part 文件继续。--zip 下载 Jenkins 打包的全部制品 archive.zip。`,
+		Run: func(cmd *cobra.Command, args []string) {
+			env := mustInit(ENV)
+			job, err := selectJob(env, args[0])
+			check(err)
+			pattern := ""
+			if len(args) > 2 {
+				pattern = args[2]
+			}
+			check(getArtifacts(env, job, args[1], pattern, opts))
+		},
+		Args: cobra.RangeArgs(2, 3),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkBuild(args[1]); err != nil {
+				return err
+			}
+			if len(args) > 2 {
+				if opts.zip {
+					return fmt.Errorf("PATTERN can not be used with --zip")
+				}
+				if _, err := path.Match(args[2], ""); err != nil {
+					return fmt.Errorf("无效的通配符 %s: %w", args[2], err)
+				}
+			}
+			return preRunE(cmd, args)
+		},
+	}
+	getCmd.Flags().StringVarP(&opts.dir, "dir", "d", ".", "directory to save the artifacts to")
+	getCmd.Flags().BoolVar(&opts.zip, "zip", false, "download all artifacts as a single archive.zip")
+	for _, c := range []*cobra.Command{artifactsCmd, getCmd} {
+		c.Flags().StringVarP(&ENV, "name", "n", "", "current Jenkins name")
+	}
+	artifactsCmd.AddCommand(getCmd)
+	rootCmd.AddCommand(artifactsCmd)
+}
+
+func listArtifacts(env jj.Env, job string, build string) error {
+	number, err := buildNumber(env, job, build)
+	if err != nil {
+		return err
+	}
+	artifacts, err := jj.GetArtifacts(env, job, number)
+	if err != nil {
+		return err
+	}
+	return printOutput(artifacts, func(out io.Writer, wide bool) {
+		if len(artifacts) == 0 {
+			fmt.Fprintf(out, "构建 %s #%d 没有制品\n", job, number)
+			return
+		}
+		w := new(tabwriter.Writer)
+		w.Init(out, 0, 8, 1, ' ', tabwriter.AlignRight)
+		if !noheader {
+			fmt.Fprintf(w, "%s\t %s\t\n", "Size", "Path")
+		}
+		for _, a := range artifacts {
+			size := formatSize(a.Size)
+			if wide {
+				size = fmt.Sprint(a.Size)
+			}
+			fmt.Fprintf(w, "%s\t %s\t\n", size, a.RelativePath)
+		}
+		w.Flush()
+	})
+}
+
+func getArtifacts(env jj.Env, job string, build string, pattern string, opts artifactsOptions) error {
+	number, err := buildNumber(env, job, build)
+	if err != nil {
+		return err
+	}
+	if opts.zip {
+		file := filepath.Join(opts.dir, "archive.zip")
+		if err := jj.DownloadArchive(env, job, number, file); err != nil {
+			return err
+		}
+		fmt.Printf("✓ %s\n", file)
+		return nil
+	}
+	artifacts, err := jj.GetArtifacts(env, job, number)
+	if err != nil {
+		return err
+	}
+	artifacts = matchArtifacts(artifacts, pattern)
+	if len(artifacts) == 0 {
+		return fmt.Errorf("构建 %s #%d 没有与 '%s' 匹配的制品", job, number, pattern)
+	}
+	var mutex sync.Mutex
+	return jj.DownloadArtifacts(env, job, number, artifacts, opts.dir, func(a jj.Artifact, err error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if err != nil {
+			fmt.Printf("✗ %s: %v\n", a.RelativePath, err)
+			return
+		}
+		fmt.Printf("✓ %s (%s)\n", a.RelativePath, formatSize(a.Size))
+	})
+}
+
+// matchArtifacts 返回相对路径或文件名与通配符匹配的制品，pattern 为空时返回全部
+func matchArtifacts(artifacts []jj.Artifact, pattern string) []jj.Artifact {
+	if pattern == "" {
+		return artifacts
+	}
+	matched := []jj.Artifact{}
+	for _, a := range artifacts {
+		ok, _ := path.Match(pattern, a.RelativePath)
+		if !ok && !strings.Contains(pattern, "/") {
+			ok, _ = path.Match(pattern, a.FileName)
+		}
+		if ok {
+			matched = append(matched, a)
+		}
+	}
+	return matched
+}
+
+func formatSize(size int64) string {
+	if size < 0 {
+		return "?"
+	}
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%dB", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
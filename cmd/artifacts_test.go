@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchArtifacts(t *testing.T) {
+	artifacts := []jj.Artifact{
+		{FileName: "app.jar", RelativePath: "target/app.jar"},
+		{FileName: "app-sources.jar", RelativePath: "target/app-sources.jar"},
+		{FileName: "index.html", RelativePath: "target/docs/index.html"},
+	}
+	paths := func(artifacts []jj.Artifact) []string {
+		paths := []string{}
+		for _, a := range artifacts {
+			paths = append(paths, a.RelativePath)
+		}
+		return paths
+	}
+	assert.Len(t, matchArtifacts(artifacts, ""), 3)
+	assert.Equal(t, []string{"target/app.jar", "target/app-sources.jar"}, paths(matchArtifacts(artifacts, "*.jar")))
+	assert.Equal(t, []string{"target/docs/index.html"}, paths(matchArtifacts(artifacts, "target/*/*")))
+	assert.Equal(t, []string{}, paths(matchArtifacts(artifacts, "target/*.html")))
+}
+
+func TestFormatSize(t *testing.T) {
+	assert.Equal(t, "?", formatSize(-1))
+	assert.Equal(t, "512B", formatSize(512))
+	assert.Equal(t, "1.5KiB", formatSize(1536))
+	assert.Equal(t, "3.0MiB", formatSize(3<<20))
+}
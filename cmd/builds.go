@@ -1,8 +1,8 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
+	"io"
 	"strconv"
 	"strings"
 	"time"
@@ -36,11 +36,16 @@ func showBuilds(args []string, verbose bool) {
 	}
 
 	// Fix: Change the order of return values
-	env := jj.Init(ENV)
+	env := mustInit(ENV)
 
 	// 获取匹配的任务列表
 	jobs := findMatchingJobs(env, args[0])
 
+	// 文件夹中的任务即使不在缓存中，也可以通过完整路径直接查看
+	if len(jobs) == 0 && strings.Contains(args[0], "/") {
+		jobs = []string{args[0]}
+	}
+
 	if len(jobs) == 0 {
 		fmt.Printf("未找到匹配的任务: %s\n", args[0])
 		return
@@ -61,6 +66,7 @@ func showBuilds(args []string, verbose bool) {
 	}
 
 	// 多个匹配项，让用户选择
+	mustBeInteractive("请选择要查看的任务编号")
 	fmt.Printf("\n找到 %d 个匹配的任务:\n", len(jobs))
 	for i, job := range jobs {
 		fmt.Printf("%d. %s\n", i+1, job)
@@ -111,99 +117,100 @@ func showJobBuilds(env jj.Env, jobName string, args []string, verbose bool) {
 }
 
 func showBuildList(env jj.Env, jobName string, jobInfo *jj.JobInfo) {
-	fmt.Printf("\n任务名称: %s\n", jobName)
-	fmt.Printf("最新构建号: #%d\n", jobInfo.NextBuildNumber-1)
-	fmt.Printf("最后完成的构建: #%d\n", jobInfo.LastCompletedBuild.Number)
-	fmt.Printf("是否在队列中: %v\n\n", jobInfo.InQueue)
-
-	// 获取最近的构建列表
-	// Fix: Change jj.Req to jj.req
-	code, rsp, _, err := jj.Req(env, "GET", fmt.Sprintf("job/%s/api/json?tree=builds[number,result,timestamp,duration,building]", jobName), []byte{})
-	if err != nil || code != 200 {
+	builds, err := jj.GetBuilds(env, jobName)
+	if err != nil {
 		fmt.Printf("获取构建列表失败: %v\n", err)
 		return
 	}
 
-	var builds struct {
-		Builds []struct {
-			Number    int    `json:"number"`
-			Result    string `json:"result"`
-			Timestamp int64  `json:"timestamp"`
-			Duration  int    `json:"duration"`
-			Building  bool   `json:"building"`
-		} `json:"builds"`
-	}
-
-	if err := json.Unmarshal(rsp, &builds); err != nil {
-		fmt.Printf("解析构建列表失败: %v\n", err)
-		return
-	}
-
-	fmt.Printf("最近构建列表:\n")
-	fmt.Printf("构建号\t状态\t\t耗时\t\t开始时间\t\t控制台输出\n")
-	fmt.Printf("--------------------------------------------------------------------------------\n")
+	check(printOutput(builds, func(w io.Writer, wide bool) {
+		fmt.Fprintf(w, "\n任务名称: %s\n", jobName)
+		fmt.Fprintf(w, "最新构建号: #%d\n", jobInfo.NextBuildNumber-1)
+		fmt.Fprintf(w, "最后完成的构建: #%d\n", jobInfo.LastCompletedBuild.Number)
+		fmt.Fprintf(w, "是否在队列中: %v\n\n", jobInfo.InQueue)
 
-	for _, build := range builds.Builds {
-		status := build.Result
-		if build.Building {
-			status = "构建中"
-		} else if status == "" {
-			status = "未知"
+		fmt.Fprintf(w, "最近构建列表:\n")
+		if wide {
+			fmt.Fprintf(w, "构建号\t状态\t\t耗时\t\t开始时间\t\t控制台输出\t参数\n")
+		} else {
+			fmt.Fprintf(w, "构建号\t状态\t\t耗时\t\t开始时间\t\t控制台输出\n")
 		}
+		fmt.Fprintf(w, "--------------------------------------------------------------------------------\n")
+
+		for _, build := range builds {
+			status := build.Result
+			if build.Building {
+				status = "构建中"
+			} else if status == "" {
+				status = "未知"
+			}
 
-		startTime := time.Unix(build.Timestamp/1000, 0).Format("2006-01-02 15:04:05")
-		duration := fmt.Sprintf("%dm%ds", build.Duration/60000, (build.Duration%60000)/1000)
-		consoleUrl := fmt.Sprintf("%s/job/%s/%d/console", env.Url, jobName, build.Number)
+			startTime := time.Unix(build.Timestamp/1000, 0).Format("2006-01-02 15:04:05")
+			duration := fmt.Sprintf("%dm%ds", build.Duration/60000, (build.Duration%60000)/1000)
+			consoleUrl := fmt.Sprintf("%s/%s/%d/console", strings.TrimSuffix(env.Url, "/"), jj.JobPath(jobName).URL(), build.Number)
+
+			fmt.Fprintf(w, "#%d\t%s\t\t%s\t%s\t%s",
+				build.Number,
+				status,
+				duration,
+				startTime,
+				consoleUrl)
+			if wide {
+				params := []string{}
+				for _, p := range build.Parameters {
+					params = append(params, p.Name+"="+p.Value)
+				}
+				fmt.Fprintf(w, "\t%s", strings.Join(params, ","))
+			}
+			fmt.Fprintln(w)
+		}
+	}))
+}
 
-		fmt.Printf("#%d\t%s\t\t%s\t%s\t%s\n",
-			build.Number,
-			status,
-			duration,
-			startTime,
-			consoleUrl)
-	}
+// buildDetail 是 -o json|yaml 输出的构建详情
+type buildDetail struct {
+	*jj.BuildInfo
+	Parameters []jj.Parameter `json:"parameters"`
+	Console    string         `json:"console,omitempty"`
 }
 
 func showBuildDetail(env jj.Env, jobName string, buildNum int, verbose bool) {
-	// Fix: Change jj.Req to jj.req
-	code, rsp, _, err := jj.Req(env, "GET", fmt.Sprintf("job/%s/%d/api/json", jobName, buildNum), []byte{})
-	if err != nil || code != 200 {
+	buildInfo, err := jj.GetBuildInfo(env, jobName, buildNum)
+	if err != nil {
 		fmt.Printf("获取构建详情失败: %v\n", err)
 		return
 	}
-
-	var buildInfo jj.BuildInfo
-	if err := json.Unmarshal(rsp, &buildInfo); err != nil {
-		fmt.Printf("解析构建详情失败: %v\n", err)
-		return
-	}
-
-	fmt.Printf("\n构建详情 #%d:\n", buildNum)
-	fmt.Printf("----------------------------------------\n")
-	fmt.Printf("状态: %s\n", buildInfo.Result)
-	fmt.Printf("构建中: %v\n", buildInfo.Building)
-	fmt.Printf("持续时间: %ds\n", buildInfo.Duration/1000)
-	fmt.Printf("控制台输出: %s/job/%s/%d/console\n", env.Url, jobName, buildNum)
+	detail := buildDetail{BuildInfo: buildInfo, Parameters: buildInfo.Parameters()}
 
 	if verbose {
-		// 获取并显示控制台输出
-		code, rsp, _, err = jj.Req(env, "GET", fmt.Sprintf("job/%s/%d/consoleText", jobName, buildNum), []byte{})
+		// 获取控制台输出
+		code, rsp, _, err := jj.Req(env, "GET", fmt.Sprintf("%s/%d/consoleText", jj.JobPath(jobName).URL(), buildNum), []byte{})
 		if err != nil || code != 200 {
 			fmt.Printf("获取控制台输出失败: %v\n", err)
 			return
 		}
-
-		fmt.Printf("\n控制台输出:\n")
-		fmt.Printf("----------------------------------------\n")
-		fmt.Printf("%s\n", string(rsp))
+		detail.Console = string(rsp)
 	}
 
-	if len(buildInfo.Actions) > 0 {
-		fmt.Println("\n构建参数:")
-		for _, action := range buildInfo.Actions {
-			for _, param := range action.Parameters {
-				fmt.Printf("%s: %s\n", param.Name, param.Value)
+	check(printOutput(detail, func(w io.Writer, wide bool) {
+		fmt.Fprintf(w, "\n构建详情 #%d:\n", buildNum)
+		fmt.Fprintf(w, "----------------------------------------\n")
+		fmt.Fprintf(w, "状态: %s\n", buildInfo.Result)
+		fmt.Fprintf(w, "构建中: %v\n", buildInfo.Building)
+		fmt.Fprintf(w, "持续时间: %ds\n", buildInfo.Duration/1000)
+		fmt.Fprintf(w, "控制台输出: %s/%s/%d/console\n", strings.TrimSuffix(env.Url, "/"), jj.JobPath(jobName).URL(), buildNum)
+
+		if verbose {
+			fmt.Fprintf(w, "\n控制台输出:\n")
+			fmt.Fprintf(w, "----------------------------------------\n")
+			fmt.Fprintf(w, "%s\n", detail.Console)
+		}
+
+		if len(detail.Parameters) > 0 {
+			fmt.Fprintln(w, "\n构建参数:")
+			for _, param := range detail.Parameters {
+				fmt.Fprintf(w, "%s: %s\n", param.Name, param.Value)
 			}
 		}
-	}
+	}))
 }
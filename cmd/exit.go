@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Exit codes of jj, scripts can rely on them
+const (
+	exitSuccess        = 0
+	exitClientError    = 1 // jj itself failed: bad arguments, config, network
+	exitFailure        = 2 // the build or a downstream build is FAILURE
+	exitUnstable       = 3 // the build or a downstream build is UNSTABLE
+	exitAborted        = 4 // the build was aborted or not built
+	exitTimeout        = 5 // jj stopped waiting for the build
+	exitQueueCancelled = 6 // the queue item was cancelled before it started
+	exitUnknownResult  = 7 // Jenkins reported a result unknown to jj
+)
+
+var errTimeout = errors.New("timeout")
+var errQueueCancelled = errors.New("queue item has been cancelled")
+
+// resultError is returned when a watched build has not succeeded
+type resultError struct {
+	job    string
+	number int
+	result string
+}
+
+func (e *resultError) Error() string {
+	return fmt.Sprintf("%s #%d: %s", e.job, e.number, e.result)
+}
+
+// exitCode maps the outcome of a command to the exit code
+func exitCode(err error) int {
+	var re *resultError
+	switch {
+	case err == nil:
+		return exitSuccess
+	case errors.As(err, &re):
+		switch re.result {
+		case "SUCCESS":
+			return exitSuccess
+		case "FAILURE":
+			return exitFailure
+		case "UNSTABLE":
+			return exitUnstable
+		case "ABORTED", "NOT_BUILT":
+			return exitAborted
+		}
+		return exitUnknownResult
+	case errors.Is(err, errTimeout):
+		return exitTimeout
+	case errors.Is(err, errQueueCancelled):
+		return exitQueueCancelled
+	}
+	return exitClientError
+}
+
+// exit terminates jj with the exit code of err, client errors are printed
+// the same way as check does
+func exit(err error) {
+	code := exitCode(err)
+	if code == exitClientError {
+		fmt.Printf("\nError: %s\n", err.Error())
+	}
+	os.Exit(code)
+}
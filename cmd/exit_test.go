@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj"
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj/jjtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExitCode(t *testing.T) {
+	tcases := []struct {
+		err  error
+		code int
+	}{
+		{nil, exitSuccess},
+		{&resultError{result: "SUCCESS"}, exitSuccess},
+		{&resultError{result: "FAILURE"}, exitFailure},
+		{&resultError{result: "UNSTABLE"}, exitUnstable},
+		{&resultError{result: "ABORTED"}, exitAborted},
+		{&resultError{result: "NOT_BUILT"}, exitAborted},
+		{&resultError{result: "WEIRD"}, exitUnknownResult},
+		{fmt.Errorf("watch: %w", errTimeout), exitTimeout},
+		{errQueueCancelled, exitQueueCancelled},
+		{errors.New("network is down"), exitClientError},
+	}
+	for _, tc := range tcases {
+		assert.Equal(t, tc.code, exitCode(tc.err), "%v", tc.err)
+	}
+}
+
+func TestWaitForExecutorCancelled(t *testing.T) {
+	srv := jjtest.New()
+	defer srv.Close()
+	srv.AddJob("app").Script = jjtest.Script{QueuePolls: 100}
+	env := srv.Env()
+
+	err, queueId := jj.Build(env, "app", "")
+	assert.NoError(t, err)
+	id, _ := strconv.Atoi(queueId)
+	assert.NoError(t, jj.CancelQueue(env, id))
+	_, err = waitForExecutor(env, id)
+	assert.Equal(t, exitQueueCancelled, exitCode(err))
+}
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"github.com/gocruncher/jenkins-job-cli/cmd/jj"
 	"github.com/spf13/cobra"
+	"io"
 	"os"
 	"regexp"
 	"strings"
@@ -64,10 +65,12 @@ func init() {
 		Short: "Display any resources(settings, jobs)",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if len(args) == 0 && ENV != "" {
-				showAllJobs(jj.Init(ENV))
+				showAllJobs(mustInit(ENV))
 				os.Exit(0)
 			}
 			if len(args) > 1 && args[0] == "compline" {
+				// completion always reads the table
+				outputFormat = ""
 				space := regexp.MustCompile(`\s+`)
 				s := space.ReplaceAllString(args[1], " ")
 				s = strings.ReplaceAll(s, "=", " ")
@@ -115,7 +118,7 @@ func showJobs(eName string) {
 	// Run your long running function in it's own goroutine and pass back it's
 	// response into our channel.
 	go func() {
-		env := jj.Init(eName)
+		env := mustInit(eName)
 		showAllJobs(env)
 		ch <- struct{}{}
 	}()
@@ -129,33 +132,57 @@ func showJobs(eName string) {
 }
 
 func showAllEnvs() {
-	w := new(tabwriter.Writer)
-	// Format in tab-separated columns with a tab stop of 8.
-	w.Init(os.Stdout, 0, 8, 0, '\t', 0)
-	if !noheader {
-		fmt.Fprintf(w, "%s\t%s\t%s\n", "Name", "URL", "Authorization")
-	}
+	envs := []jj.EnvSummary{}
+	def := jj.GetDefEnv()
 	for _, e := range jj.GetEnvs() {
-		fmt.Fprintf(w, "%s\t%s\t%s\n", e.Name, e.Url, e.Type)
+		summary := e.Summary()
+		summary.Default = e.Name == def
+		envs = append(envs, summary)
 	}
-	fmt.Fprintln(w)
-	w.Flush()
+	check(printOutput(envs, func(out io.Writer, wide bool) {
+		w := new(tabwriter.Writer)
+		// Format in tab-separated columns with a tab stop of 8.
+		w.Init(out, 0, 8, 0, '\t', 0)
+		if !noheader {
+			if wide {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", "Name", "URL", "Authorization", "Login", "TLS Verify", "Default")
+			} else {
+				fmt.Fprintf(w, "%s\t%s\t%s\n", "Name", "URL", "Authorization")
+			}
+		}
+		for _, e := range envs {
+			if wide {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%v\t%v\n", e.Name, e.URL, e.Type, e.Login, e.TLSVerify, e.Default)
+			} else {
+				fmt.Fprintf(w, "%s\t%s\t%s\n", e.Name, e.URL, e.Type)
+			}
+		}
+		fmt.Fprintln(w)
+		w.Flush()
+	}))
 }
 
 func showAllJobs(env jj.Env) {
-	w := new(tabwriter.Writer)
-	// Format in tab-separated columns with a tab stop of 8.
-	w.Init(os.Stdout, 0, 8, 0, '\t', 0)
-	if !noheader {
-		fmt.Fprintf(w, "%s\t%s\n", "Name", "URL")
-	}
-	for _, view := range jj.GetBundle(env).Views {
-		//fmt.Printf("views: %+v",view)
-		for _, j := range view.Jobs {
-			fmt.Fprintf(w, "%s\t%s\n", j.Name, j.URL)
+	jobs := jj.GetBundle(env).AllJobs()
+	check(printOutput(jobs, func(out io.Writer, wide bool) {
+		w := new(tabwriter.Writer)
+		// Format in tab-separated columns with a tab stop of 8.
+		w.Init(out, 0, 8, 0, '\t', 0)
+		if !noheader {
+			if wide {
+				fmt.Fprintf(w, "%s\t%s\t%s\n", "Name", "URL", "Class")
+			} else {
+				fmt.Fprintf(w, "%s\t%s\n", "Name", "URL")
+			}
 		}
-	}
-	fmt.Fprintln(w)
-	w.Flush()
-
+		for _, j := range jobs {
+			if wide {
+				fmt.Fprintf(w, "%s\t%s\t%s\n", j.Name, j.URL, j.Class)
+			} else {
+				fmt.Fprintf(w, "%s\t%s\n", j.Name, j.URL)
+			}
+		}
+		fmt.Fprintln(w)
+		w.Flush()
+	}))
 }
@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/chzyer/readline"
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj"
+	"github.com/spf13/cobra"
+)
+
+type inputOptions struct {
+	approve bool
+	abort   bool
+	id      string
+}
+
+func init() {
+	var opts inputOptions
+	inputCmd := &cobra.Command{
+		Use:   "input JOB BUILD|lastBuild",
+		Short: "处理 Pipeline 构建等待的 input 步骤",
+		Long: `显示 Pipeline 构建等待的 input 步骤，--approve 继续执行，--abort 中止构建。
+input 的参数用 -a key=val 指定，未指定的参数提交 input 步骤的默认值，选项参数默认为第一个选项。
+构建同时等待多个 input 时用 --id 选择。`,
+		Run: func(cmd *cobra.Command, args []string) {
+			env := mustInit(ENV)
+			job, err := selectJob(env, args[0])
+			check(err)
+			check(handleInput(env, job, args[1], opts))
+		},
+		Args: cobra.ExactArgs(2),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkBuild(args[1]); err != nil {
+				return err
+			}
+			if opts.approve && opts.abort {
+				return errors.New("--approve and --abort can not be used together")
+			}
+			if len(inputArgs.args) > 0 && !opts.approve {
+				return errors.New("-a can only be used with --approve")
+			}
+			if err := inputArgs.validate(); err != nil {
+				return err
+			}
+			return preRunE(cmd, args)
+		},
+	}
+	inputCmd.Flags().StringVarP(&ENV, "name", "n", "", "current Jenkins name")
+	inputCmd.Flags().BoolVar(&opts.approve, "approve", false, "proceed with the input step")
+	inputCmd.Flags().BoolVar(&opts.abort, "abort", false, "abort the input step and the build")
+	inputCmd.Flags().StringVar(&opts.id, "id", "", "id of the input step when the build waits for several")
+	inputCmd.Flags().StringArrayVarP(&inputArgs.args, "arg", "a", []string{}, "parameters of the input step. Usage: -a key=val")
+	rootCmd.AddCommand(inputCmd)
+}
+
+func handleInput(env jj.Env, job string, build string, opts inputOptions) error {
+	number, err := buildNumber(env, job, build)
+	if err != nil {
+		return err
+	}
+	inputs, err := jj.GetPendingInputs(env, job, number)
+	if errors.Is(err, jj.ErrNotFound) {
+		return fmt.Errorf("%s 不是 Pipeline 任务", job)
+	}
+	if err != nil {
+		return err
+	}
+	if len(inputs) == 0 {
+		return fmt.Errorf("构建 %s #%d 没有等待中的 input", job, number)
+	}
+	if !opts.approve && !opts.abort {
+		return printOutput(inputs, func(w io.Writer, wide bool) {
+			for _, input := range inputs {
+				writeInput(w, input)
+			}
+		})
+	}
+	input, err := selectInput(inputs, opts.id)
+	if err != nil {
+		return err
+	}
+	if opts.abort {
+		if err := jj.AbortInput(env, job, number, input.ID); err != nil {
+			return err
+		}
+		fmt.Printf("已中止 %s #%d\n", job, number)
+		return nil
+	}
+	values, err := inputValues(input, inputArgs)
+	if err != nil {
+		return err
+	}
+	if err := jj.ProceedInput(env, job, number, input, values); err != nil {
+		return err
+	}
+	fmt.Printf("已批准 %s #%d 的 %s\n", job, number, input.ID)
+	return nil
+}
+
+func selectInput(inputs []jj.InputAction, id string) (jj.InputAction, error) {
+	ids := []string{}
+	for _, input := range inputs {
+		if input.ID == id || id == "" && len(inputs) == 1 {
+			return input, nil
+		}
+		ids = append(ids, input.ID)
+	}
+	if id == "" {
+		return jj.InputAction{}, fmt.Errorf("构建等待多个 input，请用 --id 选择: %s", strings.Join(ids, ", "))
+	}
+	return jj.InputAction{}, fmt.Errorf("没有 id 为 %s 的 input，等待中的有: %s", id, strings.Join(ids, ", "))
+}
+
+// inputValues 按 input 的参数定义检查 -a 指定的值，Jenkins 不会为未提交的参数
+// 使用默认值，所以未指定的参数提交 input 步骤的默认值
+func inputValues(input jj.InputAction, args arguments) (map[string]string, error) {
+	defs := map[string]jj.ParameterDefinitions{}
+	values := map[string]string{}
+	for _, p := range input.Inputs {
+		pd := p.ParameterDefinition()
+		defs[p.Name] = pd
+		values[p.Name] = pd.DefaultParameterValue.Value
+	}
+	for _, arg := range args.args {
+		kv := strings.SplitN(arg, "=", 2)
+		pd, ok := defs[kv[0]]
+		if !ok {
+			return nil, fmt.Errorf("%s 不是 input %s 的参数", kv[0], input.ID)
+		}
+		val, err := resolveValue(kv[1])
+		if err != nil {
+			return nil, err
+		}
+		if values[kv[0]], err = pd.Normalize(val); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+func writeInput(w io.Writer, input jj.InputAction) {
+	fmt.Fprintf(w, "⏸ %s: %s\n", input.ID, input.Message)
+	for _, p := range input.Inputs {
+		pd := p.ParameterDefinition()
+		line := fmt.Sprintf("    %s (%s)", pd.Name, pd.Kind())
+		if len(pd.Choices) > 0 {
+			line += " " + strings.Join(pd.Choices, "|")
+		}
+		if def := pd.DefaultParameterValue.Value; def != "" && pd.Kind() != jj.ParamPassword {
+			line += " = " + def
+		}
+		if pd.Description != "" {
+			line += "  " + pd.Description
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+// answerInputs 处理构建等待的 input 步骤，每个 input 只处理一次：交互模式下
+// 询问继续还是中止，非交互模式下提示如何用 jj input 处理
+func answerInputs(env jj.Env, job string, number int, seen map[string]bool, chMsg chan string, keyCh chan string) {
+	inputs, err := jj.GetPendingInputs(env, job, number)
+	if err != nil {
+		return
+	}
+	for _, input := range inputs {
+		if seen[input.ID] {
+			continue
+		}
+		seen[input.ID] = true
+		if !interactive() {
+			chMsg <- fmt.Sprintf("⏸ %s\nwaiting for input %s: jj input %s %d --approve|--abort", input.Message, input.ID, job, number)
+			continue
+		}
+		promptInput(env, job, number, input, keyCh)
+	}
+}
+
+func promptInput(env jj.Env, job string, number int, input jj.InputAction, keyCh chan string) {
+	// 与 listenInterrupt 相同，暂停进度条和按键监听后再读取输入
+	barMutex.Lock()
+	stdinListener.NewListener()
+	readline.Stdin = stdinListener
+	defer func() {
+		barMutex.Unlock()
+		if keyCh != nil {
+			go listenKeys(keyCh)
+		}
+	}()
+
+	fmt.Printf("\n⏸ %s\n", input.Message)
+	proceed := input.ProceedText
+	if proceed == "" {
+		proceed = "Proceed"
+	}
+	var err error
+	if getAnswer(proceed+" or Abort [p/a]: ", "p", []string{"p", "a"}) == "a" {
+		if err = jj.AbortInput(env, job, number, input.ID); err == nil {
+			fmt.Println("aborted")
+		}
+	} else {
+		values := map[string]string{}
+		for _, p := range input.Inputs {
+			values[p.Name] = askParam(env, job, p.ParameterDefinition())
+		}
+		if err = jj.ProceedInput(env, job, number, input, values); err == nil {
+			fmt.Println("proceeding...")
+		}
+	}
+	if err != nil {
+		fmt.Printf("failed to answer the input: %v, use jj input %s %d\n", err, job, number)
+	}
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj"
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj/jjtest"
+	"github.com/stretchr/testify/assert"
+)
+
+// inputJob pauses on the input after the log is released
+func inputJob(srv *jjtest.Server, name string, log ...string) *jjtest.Job {
+	target := jj.ParameterDefinitions{Name: "TARGET", Type: "ChoiceParameterDefinition", Choices: []string{"uat", "prod"}}
+	reason := jj.ParameterDefinitions{Name: "REASON", Type: "StringParameterDefinition"}
+	reason.DefaultParameterValue.Value = "release"
+	job := srv.AddJob(name)
+	job.Script = jjtest.Script{Log: log}
+	job.Stages = []jjtest.Stage{{Name: "Deploy"}}
+	job.Input = &jjtest.Input{ID: "Approve", Message: "Deploy to prod?", Params: []jj.ParameterDefinitions{target, reason}}
+	return job
+}
+
+func TestHandleInput(t *testing.T) {
+	srv := jjtest.New()
+	defer srv.Close()
+	inputJob(srv, "deploy")
+	env := useServer(t, srv)
+	startBuild(t, env, "deploy")
+	startBuild(t, env, "deploy")
+	startBuild(t, env, "deploy")
+	defer func() { inputArgs = arguments{} }()
+
+	inputArgs = arguments{args: []string{"TARGET=dev"}}
+	assert.Error(t, handleInput(env, "deploy", "1", inputOptions{approve: true}))
+	assert.Error(t, handleInput(env, "deploy", "1", inputOptions{approve: true, id: "Other"}))
+
+	inputArgs = arguments{args: []string{"TARGET=prod"}}
+	assert.NoError(t, handleInput(env, "deploy", "1", inputOptions{approve: true}))
+	// parameters without -a are submitted with the defaults of the input step
+	inputArgs = arguments{}
+	assert.NoError(t, handleInput(env, "deploy", "2", inputOptions{approve: true}))
+	assert.NoError(t, handleInput(env, "deploy", "3", inputOptions{abort: true}))
+	builds := srv.Builds("deploy")
+	assert.Equal(t, map[string]interface{}{"TARGET": "prod", "REASON": "release"}, builds[0].InputValues)
+	assert.Equal(t, map[string]interface{}{"TARGET": "uat", "REASON": "release"}, builds[1].InputValues)
+	assert.Equal(t, "ABORTED", builds[2].Result)
+
+	assert.Error(t, handleInput(env, "deploy", "1", inputOptions{}), "nothing is pending anymore")
+}
+
+func TestRunWaitsForInput(t *testing.T) {
+	srv := jjtest.New()
+	defer srv.Close()
+	inputJob(srv, "deploy", "building\n")
+	env := useServer(t, srv)
+
+	nonInteractive = true
+	defer func() {
+		nonInteractive = false
+		inputArgs = arguments{}
+	}()
+	var err error
+	out := captureStdout(t, func() {
+		// an approver answers from another terminal after jj has noticed the input
+		go func() {
+			for srv.RequestCount("/job/deploy/1/wfapi/pendingInputActions") == 0 {
+				time.Sleep(10 * time.Millisecond)
+			}
+			handleInput(env, "deploy", "1", inputOptions{approve: true})
+		}()
+		err = runJob("deploy")
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, out, "⏸ Deploy to prod?\nwaiting for input Approve: jj input deploy 1 --approve|--abort\n")
+}
+
+func TestInputWaitIsNotTimedOut(t *testing.T) {
+	srv := jjtest.New()
+	defer srv.Close()
+	inputJob(srv, "approve-deploy", "building\n")
+	env := useServer(t, srv)
+
+	nonInteractive = true
+	watchTimeout = 100 * time.Millisecond
+	defer func() {
+		nonInteractive = false
+		watchTimeout = 10 * time.Minute
+		inputArgs = arguments{}
+	}()
+	var err error
+	captureStdout(t, func() {
+		// the approver answers long after the timeout
+		go func() {
+			for srv.RequestCount("/job/approve-deploy/1/wfapi/pendingInputActions") == 0 {
+				time.Sleep(10 * time.Millisecond)
+			}
+			time.Sleep(5 * watchTimeout)
+			handleInput(env, "approve-deploy", "1", inputOptions{approve: true})
+		}()
+		err = runJob("approve-deploy")
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "SUCCESS", srv.Builds("approve-deploy")[0].Result)
+}
@@ -0,0 +1,746 @@
+package jj
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"log"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Client talks to a single Jenkins. Unlike the package level functions it
+// does not depend on ~/.jj/config.yaml, so it can be embedded into other tools.
+type Client struct {
+	env         Env
+	httpClient  *http.Client
+	cacheDir    string
+	logger      *log.Logger
+	concurrency int
+	// initErr is returned by every request when the client could not be set up
+	initErr error
+
+	// Jenkins binds the CSRF crumb to the session cookie, so the crumb is
+	// cached together with the cookie jar of httpClient
+	crumbMutex   sync.Mutex
+	crumbFetched bool
+	crumbField   string
+	crumb        string
+
+	// requests are held back until throttledUntil when the Jenkins
+	// answers 429 or 503 with Retry-After
+	throttleMutex  sync.Mutex
+	throttledUntil time.Time
+
+	// the secret is resolved on the first request which needs it
+	secretMutex sync.Mutex
+	secret      *string
+
+	mutex  sync.Mutex
+	bundle *Bundle
+}
+
+type Option func(*Client)
+
+// WithHTTPClient makes the client to send requests through hc,
+// a cookie jar is added to a copy of hc when it has none
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithCacheDir sets the directory of the views and jobs cache,
+// the cache is kept only in memory when the directory is not set
+func WithCacheDir(dir string) Option {
+	return func(c *Client) {
+		c.cacheDir = dir
+	}
+}
+
+// WithLogger enables logging of every request and response
+func WithLogger(logger *log.Logger) Option {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// WithConcurrency overrides Env.Concurrency
+func WithConcurrency(n int) Option {
+	return func(c *Client) {
+		c.concurrency = n
+	}
+}
+
+const defaultConcurrency = 4
+
+// newTransport keeps connections alive between polls of a build, so the
+// handshake is paid once per Jenkins. Responses are gzipped unless
+// DisableCompression is set and HTTP/2 is used when the server offers it
+func newTransport(tlsConfig *tls.Config) *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSClientConfig:       tlsConfig,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   16,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		// only waiting for the response is bounded, reading the body of
+		// big logs and artifacts may take much longer
+		ResponseHeaderTimeout: 30 * time.Second,
+	}
+}
+
+func NewClient(env Env, opts ...Option) *Client {
+	c := &Client{env: env}
+	for _, opt := range opts {
+		opt(c)
+	}
+	tlsConfig, err := env.TLS.Config()
+	if err != nil {
+		c.initErr = err
+	}
+	hc := http.Client{
+		Transport: newTransport(tlsConfig),
+	}
+	if c.httpClient != nil {
+		hc = *c.httpClient
+	}
+	if c.concurrency <= 0 {
+		c.concurrency = env.Concurrency
+	}
+	if c.concurrency <= 0 {
+		c.concurrency = defaultConcurrency
+	}
+	if hc.Jar == nil {
+		hc.Jar, _ = cookiejar.New(nil)
+	}
+	c.httpClient = &hc
+	return c
+}
+
+func (c *Client) Env() Env {
+	return c.env
+}
+
+func (c *Client) Check(ctx context.Context) error {
+	code, _, _, err := c.Req(ctx, "GET", "api/json", []byte{})
+	if err != nil {
+		return err
+	}
+	if code != 200 {
+		return httpError(code, c.url("api/json"))
+	}
+	return nil
+}
+
+// Bundle returns the cached views and jobs, it is nil until LoadBundle is called
+func (c *Client) Bundle() *Bundle {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.bundle
+}
+
+// LoadBundle reads the bundle from the cache and refreshes it in background,
+// without the cache the bundle is fetched synchronously
+func (c *Client) LoadBundle(ctx context.Context) error {
+	var bundle Bundle
+	cachebin, err := ioutil.ReadFile(c.cachePath())
+	if err == nil {
+		err = json.Unmarshal(cachebin, &bundle)
+	}
+	if err != nil {
+		return c.RefreshBundle(ctx)
+	}
+	c.mutex.Lock()
+	c.bundle = &bundle
+	c.mutex.Unlock()
+	go c.RefreshBundle(context.Background())
+	return nil
+}
+
+// RefreshBundle fetches all views and walks all folders of the Jenkins,
+// at most Env.Concurrency requests are sent at once
+func (c *Client) RefreshBundle(ctx context.Context) error {
+	var rsp struct {
+		Views []View `json:"views"`
+	}
+	if err := c.reqJSON(ctx, "POST", "api/json?tree=views[name,url]", &rsp); err != nil {
+		return err
+	}
+	err := c.parallel(len(rsp.Views), func(i int) error {
+		return c.reqJSON(ctx, "POST", "view/"+rsp.Views[i].Name+"/api/json?tree=name,url,jobs[name,url]", &rsp.Views[i])
+	})
+	if err != nil {
+		return err
+	}
+	folders := []Job{}
+	crawled := map[string]bool{}
+	for _, view := range rsp.Views {
+		for _, j := range view.Jobs {
+			if j.IsFolder() && !crawled[j.URL] {
+				crawled[j.URL] = true
+				folders = append(folders, j)
+			}
+		}
+	}
+	nested := make([][]Job, len(folders))
+	c.parallel(len(folders), func(i int) error {
+		nested[i] = c.fetchFolder(ctx, JobPath(folders[i].Name), 1)
+		return nil
+	})
+	jobs := []Job{}
+	for _, n := range nested {
+		jobs = append(jobs, n...)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.bundle == nil {
+		c.bundle = &Bundle{Name: c.env.Name, JobsInfo: []JobInfo{}}
+	}
+	c.bundle.Views = rsp.Views
+	c.bundle.Jobs = jobs
+	c.updateCache()
+	return nil
+}
+
+// parallel calls fn for every index from 0 to n-1 running at most
+// c.concurrency calls at once, it returns the first error by index
+func (c *Client) parallel(n int, fn func(i int) error) error {
+	sem := make(chan struct{}, c.concurrency)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = fn(i)
+			<-sem
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const maxFolderDepth = 10
+
+// fetchFolder walks the folder recursively and returns all nested jobs
+// named by their full path
+func (c *Client) fetchFolder(ctx context.Context, folder JobPath, depth int) []Job {
+	jobs := []Job{}
+	if depth > maxFolderDepth {
+		return jobs
+	}
+	var rsp struct {
+		Jobs []Job `json:"jobs"`
+	}
+	if err := c.reqJSON(ctx, "POST", folder.URL()+"/api/json?tree=jobs[name,url]", &rsp); err != nil {
+		return jobs
+	}
+	for _, j := range rsp.Jobs {
+		path := folder.Join(j.Name)
+		if j.IsFolder() {
+			jobs = append(jobs, c.fetchFolder(ctx, path, depth+1)...)
+			continue
+		}
+		j.Name = string(path)
+		jobs = append(jobs, j)
+	}
+	return jobs
+}
+
+func (c *Client) cachePath() string {
+	return filepath.Join(c.cacheDir, cacheFile+"."+string(c.env.Name))
+}
+
+// updateCache must be called under c.mutex
+func (c *Client) updateCache() {
+	if c.cacheDir == "" {
+		return
+	}
+	cachebin, err := json.Marshal(c.bundle)
+	if err != nil {
+		c.logf("failed to Marshal cache info, %s", err)
+		return
+	}
+	ioutil.WriteFile(c.cachePath(), cachebin, 0644)
+}
+
+// CachedJobInfo returns the job info from the bundle or nil
+func (c *Client) CachedJobInfo(job string) *JobInfo {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.bundle == nil {
+		return nil
+	}
+	for _, ji := range c.bundle.JobsInfo {
+		if ji.FullName == job || (ji.FullName == "" && ji.Name == job) {
+			return &ji
+		}
+	}
+	return nil
+}
+
+// GetJobInfo fetches the job info and puts it into the bundle
+func (c *Client) GetJobInfo(ctx context.Context, job string) (*JobInfo, error) {
+	var ji JobInfo
+	if err := c.reqJSON(ctx, "POST", JobPath(job).URL()+"/api/json", &ji); err != nil {
+		return nil, err
+	}
+	if ji.FullName == "" {
+		ji.FullName = job
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.bundle == nil {
+		c.bundle = &Bundle{Name: c.env.Name, JobsInfo: []JobInfo{}}
+	}
+	replaced := false
+	for i, cached := range c.bundle.JobsInfo {
+		if cached.FullName == ji.FullName {
+			c.bundle.JobsInfo[i] = ji
+			replaced = true
+		}
+	}
+	if !replaced {
+		c.bundle.JobsInfo = append(c.bundle.JobsInfo, ji)
+	}
+	c.updateCache()
+	return &ji, nil
+}
+
+func (c *Client) GetBuildInfo(ctx context.Context, job string, id int) (*BuildInfo, error) {
+	return c.getBuildInfo(ctx, job, strconv.Itoa(id))
+}
+
+func (c *Client) GetLastBuildInfo(ctx context.Context, job string) (*BuildInfo, error) {
+	return c.getBuildInfo(ctx, job, "lastBuild")
+}
+
+func (c *Client) GetLastSuccessfulBuildInfo(ctx context.Context, job string) (*BuildInfo, error) {
+	return c.getBuildInfo(ctx, job, "lastSuccessfulBuild")
+}
+
+func (c *Client) getBuildInfo(ctx context.Context, job string, build string) (*BuildInfo, error) {
+	var bi BuildInfo
+	if err := c.reqJSON(ctx, "POST", JobPath(job).URL()+"/"+build+"/api/json", &bi); err != nil {
+		return nil, err
+	}
+	return &bi, nil
+}
+
+// GetBuilds returns the build history of the job, newest first
+func (c *Client) GetBuilds(ctx context.Context, job string) ([]BuildSummary, error) {
+	var rsp struct {
+		Builds []BuildInfo `json:"builds"`
+	}
+	tree := "builds[number,result,timestamp,duration,building,url,actions[parameters[name,value]]]"
+	if err := c.reqJSON(ctx, "GET", JobPath(job).URL()+"/api/json?tree="+tree, &rsp); err != nil {
+		return nil, err
+	}
+	builds := []BuildSummary{}
+	for _, b := range rsp.Builds {
+		builds = append(builds, BuildSummary{
+			Number:     b.Number,
+			Result:     b.Result,
+			Building:   b.Building,
+			Timestamp:  b.Timestamp,
+			Duration:   b.Duration,
+			URL:        b.URL,
+			Parameters: b.Parameters(),
+		})
+	}
+	return builds, nil
+}
+
+// Build triggers the job and returns id of the queue item
+func (c *Client) Build(ctx context.Context, job string, query string) (int, error) {
+	target := "/build"
+	if len(query) > 0 {
+		target = "/buildWithParameters?" + query
+	}
+	path := JobPath(job).URL() + target
+	code, _, headers, err := c.Req(ctx, "POST", path, []byte{})
+	return c.queueId(path, code, headers, err)
+}
+
+// BuildWithFiles triggers the job with file parameters, files maps names
+// of the parameters to local paths which are uploaded as multipart form
+func (c *Client) BuildWithFiles(ctx context.Context, job string, values url.Values, files map[string]string) (int, error) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for name, vals := range values {
+		for _, val := range vals {
+			if err := w.WriteField(name, val); err != nil {
+				return 0, err
+			}
+		}
+	}
+	for name, path := range files {
+		f, err := os.Open(path)
+		if err != nil {
+			return 0, err
+		}
+		part, err := w.CreateFormFile(name, filepath.Base(path))
+		if err == nil {
+			_, err = io.Copy(part, f)
+		}
+		f.Close()
+		if err != nil {
+			return 0, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return 0, err
+	}
+	path := JobPath(job).URL() + "/buildWithParameters"
+	code, _, headers, err := c.ReqContent(ctx, "POST", path, w.FormDataContentType(), body.Bytes())
+	return c.queueId(path, code, headers, err)
+}
+
+// queueId reads id of the queue item from the response of build
+func (c *Client) queueId(path string, code int, headers http.Header, err error) (int, error) {
+	if err != nil {
+		return 0, err
+	}
+	if code != 201 {
+		return 0, httpError(code, c.url(path))
+	}
+	location := headers.Get("Location")
+	splitedUrl := strings.Split(strings.TrimSuffix(location, "/"), "/")
+	id, err := strconv.Atoi(splitedUrl[len(splitedUrl)-1])
+	if err != nil {
+		return 0, &Error{Kind: ErrUnexpected, Code: code, URL: c.url(path), Err: errors.New("bad Location header: " + location)}
+	}
+	return id, nil
+}
+
+// CancelJob asks Jenkins to abort the build gracefully
+func (c *Client) CancelJob(ctx context.Context, job string, id int) (string, error) {
+	return c.stopBuild(ctx, job, id, "stop")
+}
+
+// TermJob forcibly terminates a build which ignored the stop request
+func (c *Client) TermJob(ctx context.Context, job string, id int) (string, error) {
+	return c.stopBuild(ctx, job, id, "term")
+}
+
+// KillJob hard-kills a build, it is the last resort when even term did not help
+func (c *Client) KillJob(ctx context.Context, job string, id int) (string, error) {
+	return c.stopBuild(ctx, job, id, "kill")
+}
+
+func (c *Client) stopBuild(ctx context.Context, job string, id int, action string) (string, error) {
+	path := JobPath(job).URL() + "/" + strconv.Itoa(id) + "/" + action
+	code, _, _, err := c.Req(ctx, "POST", path, []byte{})
+	if err != nil {
+		return "", err
+	}
+	if code != 200 {
+		return "", httpError(code, c.url(path))
+	}
+	bi, err := c.GetBuildInfo(ctx, job, id)
+	if err != nil {
+		return "", err
+	}
+	return bi.Result, nil
+}
+
+func (c *Client) CancelQueue(ctx context.Context, id int) error {
+	path := "queue/cancelItem?id=" + strconv.Itoa(id)
+	code, _, _, err := c.Req(ctx, "POST", path, []byte{})
+	if err != nil {
+		return err
+	}
+	// depending on the version Jenkins answers with a redirect or even 404
+	// after the item has been cancelled, so only real failures are reported
+	if code == 401 || code == 403 || code >= 500 {
+		return httpError(code, c.url(path))
+	}
+	return nil
+}
+
+// Console returns the part of the build log starting from the start offset
+// and the offset of the next part
+func (c *Client) Console(ctx context.Context, job string, id int, start string) (string, string, error) {
+	part, err := c.GetConsolePart(ctx, job, id, start)
+	if err != nil {
+		return "", "", err
+	}
+	return part.Text, part.Next, nil
+}
+
+// ConsolePart is a piece of the build log
+type ConsolePart struct {
+	Text string
+	// Next is the offset of the following part
+	Next string
+	// More is set while Jenkins is still writing the log, the log is
+	// complete only after a part without More
+	More bool
+}
+
+// GetConsolePart returns the part of the build log starting from the offset
+func (c *Client) GetConsolePart(ctx context.Context, job string, id int, start string) (*ConsolePart, error) {
+	path := JobPath(job).URL() + "/" + strconv.Itoa(id) + "/logText/progressiveHtml"
+	code, rsp, h, err := c.Req(ctx, "POST", path, []byte("start="+start))
+	if err != nil {
+		return nil, err
+	}
+	if code != 200 {
+		return nil, httpError(code, c.url(path))
+	}
+	size := h.Get("X-Text-Size")
+	if size == "" {
+		size = start
+	}
+	return &ConsolePart{Text: string(rsp), Next: size, More: h.Get("X-More-Data") == "true"}, nil
+}
+
+func (c *Client) GetQueueInfo(ctx context.Context, id int) (*QueueInfo, error) {
+	var queueInfo QueueInfo
+	if err := c.reqJSON(ctx, "POST", "queue/item/"+strconv.Itoa(id)+"/api/json", &queueInfo); err != nil {
+		return nil, err
+	}
+	return &queueInfo, nil
+}
+
+func (c *Client) GetQueues(ctx context.Context) (*Queues, error) {
+	var queues Queues
+	if err := c.reqJSON(ctx, "POST", "queue/api/json", &queues); err != nil {
+		return nil, err
+	}
+	return &queues, nil
+}
+
+// getCrumb returns the crumb request field and the crumb, both are empty
+// when CSRF protection is disabled on the Jenkins
+func (c *Client) getCrumb(ctx context.Context) (string, string) {
+	c.crumbMutex.Lock()
+	defer c.crumbMutex.Unlock()
+	if c.crumbFetched {
+		return c.crumbField, c.crumb
+	}
+	code, rsp, _, err := c.do(ctx, "GET", "crumbIssuer/api/json", formContentType, []byte{}, false)
+	if err != nil {
+		return "", ""
+	}
+	c.crumbFetched = true
+	if code != 200 {
+		return "", ""
+	}
+	var crumb struct {
+		Crumb             string `json:"crumb"`
+		CrumbRequestField string `json:"crumbRequestField"`
+	}
+	if err = json.Unmarshal(rsp, &crumb); err != nil {
+		return "", ""
+	}
+	c.crumbField, c.crumb = crumb.CrumbRequestField, crumb.Crumb
+	return c.crumbField, c.crumb
+}
+
+func (c *Client) resetCrumb() {
+	c.crumbMutex.Lock()
+	defer c.crumbMutex.Unlock()
+	c.crumbFetched = false
+	c.crumbField, c.crumb = "", ""
+}
+
+// reqJSON sends the request and decodes a successful response into v
+func (c *Client) reqJSON(ctx context.Context, method, path string, v interface{}) error {
+	code, rsp, _, err := c.Req(ctx, method, path, []byte{})
+	if err != nil {
+		return err
+	}
+	if code != 200 {
+		return httpError(code, c.url(path))
+	}
+	if err = json.Unmarshal(rsp, v); err != nil {
+		return &Error{Kind: ErrDecode, Code: code, URL: c.url(path), Err: err}
+	}
+	return nil
+}
+
+func (c *Client) url(path string) string {
+	return strings.TrimSuffix(c.env.Url, "/") + "/" + strings.TrimPrefix(path, "/")
+}
+
+const formContentType = "application/x-www-form-urlencoded"
+
+// Req sends a raw request to the path relative to the Jenkins url
+func (c *Client) Req(ctx context.Context, method, path string, body []byte) (int, []byte, http.Header, error) {
+	return c.ReqContent(ctx, method, path, formContentType, body)
+}
+
+// ReqContent is Req with the body of the given content type
+func (c *Client) ReqContent(ctx context.Context, method, path, contentType string, body []byte) (int, []byte, http.Header, error) {
+	if err := c.waitThrottle(ctx); err != nil {
+		return 0, nil, nil, &Error{Kind: ErrNetwork, URL: c.url(path), Err: err}
+	}
+	code, contents, headers, err := c.do(ctx, method, path, contentType, body, method != "GET")
+	if err == nil && (code == 429 || code == 503) {
+		c.throttle(parseRetryAfter(headers, time.Now()))
+	}
+	if err == nil && code == 403 && method != "GET" && strings.Contains(string(contents), "No valid crumb") {
+		// crumb has expired together with the session, get a new one and try again
+		c.resetCrumb()
+		return c.do(ctx, method, path, contentType, body, true)
+	}
+	return code, contents, headers, err
+}
+
+// Stream sends a GET request and copies the body of a successful response
+// to w as it arrives, bodies of other responses are dropped
+func (c *Client) Stream(ctx context.Context, path string, w io.Writer) (int, http.Header, error) {
+	if err := c.waitThrottle(ctx); err != nil {
+		return 0, nil, &Error{Kind: ErrNetwork, URL: c.url(path), Err: err}
+	}
+	response, err := c.send(ctx, "GET", path, formContentType, nil, false, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode == 429 || response.StatusCode == 503 {
+		c.throttle(parseRetryAfter(response.Header, time.Now()))
+	}
+	if response.StatusCode != 200 {
+		io.Copy(ioutil.Discard, response.Body)
+		return response.StatusCode, response.Header, nil
+	}
+	if _, err := io.Copy(w, response.Body); err != nil {
+		return response.StatusCode, response.Header, &Error{Kind: ErrNetwork, Code: response.StatusCode, URL: c.url(path), Err: err}
+	}
+	c.logf("stream: %s", c.url(path))
+	return response.StatusCode, response.Header, nil
+}
+
+func (c *Client) send(ctx context.Context, method, path, contentType string, body []byte, withCrumb bool, header http.Header) (*http.Response, error) {
+	url := c.url(path)
+	if c.initErr != nil {
+		return nil, c.initErr
+	}
+	request, err := http.NewRequestWithContext(ctx, method, url, strings.NewReader(string(body)))
+	if err != nil {
+		return nil, &Error{Kind: ErrNetwork, URL: url, Err: err}
+	}
+	request.Header.Add("Accept-Language", "en-us")
+	request.Header.Add("Content-Type", contentType)
+	for key, values := range header {
+		request.Header[key] = values
+	}
+	if c.env.Type == "a" {
+		secret, err := c.getSecret(ctx)
+		if err != nil {
+			return nil, err
+		}
+		request.SetBasicAuth(c.env.Login, secret)
+	}
+	if withCrumb {
+		if field, crumb := c.getCrumb(ctx); field != "" {
+			request.Header.Set(field, crumb)
+		}
+	}
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return nil, &Error{Kind: ErrNetwork, URL: url, Err: err}
+	}
+	return response, nil
+}
+
+func (c *Client) do(ctx context.Context, method, path, contentType string, body []byte, withCrumb bool) (int, []byte, http.Header, error) {
+	url := c.url(path)
+	response, err := c.send(ctx, method, path, contentType, body, withCrumb, nil)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	defer response.Body.Close()
+	contents, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return 0, nil, nil, &Error{Kind: ErrNetwork, Code: response.StatusCode, URL: url, Err: err}
+	}
+	c.logf("req: %s\t data: %s type: %s\n rsp: %s", url, body, response.Header.Get("Content-Type"), contents)
+	return response.StatusCode, contents, response.Header, nil
+}
+
+// RetryAfter returns how long the Jenkins asked to wait before the next request
+func (c *Client) RetryAfter() time.Duration {
+	c.throttleMutex.Lock()
+	defer c.throttleMutex.Unlock()
+	if d := time.Until(c.throttledUntil); d > 0 {
+		return d
+	}
+	return 0
+}
+
+func (c *Client) throttle(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	c.throttleMutex.Lock()
+	defer c.throttleMutex.Unlock()
+	if until := time.Now().Add(d); until.After(c.throttledUntil) {
+		c.throttledUntil = until
+	}
+}
+
+func (c *Client) waitThrottle(ctx context.Context) error {
+	d := c.RetryAfter()
+	if d == 0 {
+		return nil
+	}
+	c.logf("throttled by Retry-After, waiting %s", d)
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *Client) getSecret(ctx context.Context) (string, error) {
+	c.secretMutex.Lock()
+	defer c.secretMutex.Unlock()
+	if c.secret != nil {
+		return *c.secret, nil
+	}
+	secret, err := ResolveSecret(ctx, c.env)
+	if err != nil {
+		return "", err
+	}
+	c.secret = &secret
+	return secret, nil
+}
+
+func (c *Client) logf(format string, v ...interface{}) {
+	if c.logger != nil {
+		c.logger.Printf(format, v...)
+	}
+}
@@ -0,0 +1,53 @@
+package jj
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParallel(t *testing.T) {
+	c := NewClient(Env{Name: "parallel-test"}, WithConcurrency(3))
+	var mutex sync.Mutex
+	running, maxRunning := 0, 0
+	done := make([]bool, 10)
+	err := c.parallel(len(done), func(i int) error {
+		mutex.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mutex.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		mutex.Lock()
+		running--
+		done[i] = true
+		mutex.Unlock()
+		if i == 7 {
+			return errors.New("failed")
+		}
+		return nil
+	})
+	assert.EqualError(t, err, "failed")
+	assert.Equal(t, 3, maxRunning)
+	for _, d := range done {
+		assert.True(t, d)
+	}
+}
+
+func TestConcurrencyFromEnv(t *testing.T) {
+	assert.Equal(t, defaultConcurrency, NewClient(Env{}).concurrency)
+	assert.Equal(t, 8, NewClient(Env{Concurrency: 8}).concurrency)
+	assert.Equal(t, 2, NewClient(Env{Concurrency: 8}, WithConcurrency(2)).concurrency)
+}
+
+func TestClientTimeouts(t *testing.T) {
+	c := NewClient(Env{})
+	// streams of logs and artifacts are not limited in time
+	assert.Zero(t, c.httpClient.Timeout)
+	assert.Equal(t, 30*time.Second, c.httpClient.Transport.(*http.Transport).ResponseHeaderTimeout)
+}
@@ -1,18 +1,13 @@
 package jj
 
 import (
-	"crypto/tls"
-	"encoding/json"
+	"context"
 	"errors"
-	"fmt"
 	"io/ioutil"
 	"log"
-	"net/http"
 	"os"
-	"strconv"
 	"strings"
 	"sync"
-	"time"
 
 	"gopkg.in/yaml.v2"
 )
@@ -24,25 +19,27 @@ const cacheFile = "cache"
 const configFile = "config.yaml"
 
 var config Config
-var bundles []*Bundle
-var mutex sync.Mutex
+var configOnce sync.Once
 var ErrNoEnv = errors.New("no env")
-var ErrNoJob = errors.New("no job")
 
-func init() {
-	homeDir, _ = os.UserHomeDir()
-	homeDir = homeDir + "/.jj/"
-	initConfig()
+// ErrNoJob is kept for compatibility, GetJobInfo returns an *Error of ErrNotFound kind
+var ErrNoJob = ErrNotFound
+
+// loadConfig reads ~/.jj/config.yaml on the first use of the config
+func loadConfig() {
+	configOnce.Do(func() {
+		homeDir, _ = os.UserHomeDir()
+		homeDir = homeDir + "/.jj/"
+		initConfig()
+	})
 }
 
-func Init(envName string) Env {
+func Init(envName string) (Env, error) {
 	err, env := GetEnv(envName)
 	if err != nil {
-		panic(ErrNoEnv)
+		return env, err
 	}
-	initBundle(env)
-	return env
-
+	return env, clientFor(env).LoadBundle(context.Background())
 }
 
 type EName string
@@ -61,14 +58,46 @@ type Config struct {
 }
 
 type Bundle struct {
-	Name     EName     `json:"name"`
-	Views    []View    `json:"views"`
+	Name  EName  `json:"name"`
+	Views []View `json:"views"`
+	// Jobs holds jobs found inside Folders and Multibranch Pipelines,
+	// their names are full paths like "team/app/main"
+	Jobs     []Job     `json:"jobs"`
 	JobsInfo []JobInfo `json:"JobsInfo"`
 }
 
 type Job struct {
-	Name string `json:"name"`
-	URL  string `json:"url"`
+	Name  string `json:"name"`
+	URL   string `json:"url"`
+	Class string `json:"_class"`
+}
+
+// IsFolder reports whether the job is a container of other jobs
+// (Folder, Multibranch Pipeline or Organization Folder)
+func (j Job) IsFolder() bool {
+	return strings.Contains(j.Class, "Folder") || strings.Contains(j.Class, "MultiBranch")
+}
+
+// AllJobs returns all runnable jobs of the bundle including nested ones
+func (b *Bundle) AllJobs() []Job {
+	jobs := []Job{}
+	seen := map[string]bool{}
+	add := func(j Job) {
+		if j.IsFolder() || seen[j.Name] {
+			return
+		}
+		seen[j.Name] = true
+		jobs = append(jobs, j)
+	}
+	for _, view := range b.Views {
+		for _, j := range view.Jobs {
+			add(j)
+		}
+	}
+	for _, j := range b.Jobs {
+		add(j)
+	}
+	return jobs
 }
 
 type View struct {
@@ -79,34 +108,70 @@ type View struct {
 }
 
 type BuildInfo struct {
-	Id      string `json:"id"`
-	Actions []struct {
-		Parameters []struct {
-			Name  string `json:"name"`
-			Value string `json:"value"`
-		} `json:"parameters,omitempty"`
-		Causes []struct {
+	Id        string `json:"id"`
+	Number    int    `json:"number"`
+	URL       string `json:"url"`
+	Timestamp int64  `json:"timestamp"`
+	Actions   []struct {
+		Parameters []Parameter `json:"parameters,omitempty"`
+		Causes     []struct {
 			ShortDescription string `json:"shortDescription"`
 			UpstreamBuild    int    `json:"upstreamBuild"`
 			UpstreamProject  string `json:"upstreamProject"`
 			UpstreamURL      string `json:"upstreamUrl"`
 		} `json:"causes,omitempty"`
 	} `json:"actions"`
-	Duration int    `json:"duration"`
-	Building bool   `json:"building"`
-	Result   string `json:"result"`
-	QueueId  int    `json:"queueId"`
+	Duration  int        `json:"duration"`
+	Building  bool       `json:"building"`
+	Result    string     `json:"result"`
+	QueueId   int        `json:"queueId"`
+	Artifacts []Artifact `json:"artifacts"`
+}
+
+// Parameters returns parameters the build has been started with
+func (bi *BuildInfo) Parameters() []Parameter {
+	params := []Parameter{}
+	for _, a := range bi.Actions {
+		params = append(params, a.Parameters...)
+	}
+	return params
+}
+
+type Parameter struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// BuildSummary is a build in the history of a job
+type BuildSummary struct {
+	Number     int         `json:"number"`
+	Result     string      `json:"result"`
+	Building   bool        `json:"building"`
+	Timestamp  int64       `json:"timestamp"`
+	Duration   int         `json:"duration"`
+	URL        string      `json:"url"`
+	Parameters []Parameter `json:"parameters"`
+}
+
+// EnvSummary is the part of Env which is safe to show
+type EnvSummary struct {
+	Name      EName  `json:"name"`
+	URL       string `json:"url"`
+	Type      EType  `json:"type"`
+	Login     string `json:"login,omitempty"`
+	TLSVerify bool   `json:"tlsVerify"`
+	Default   bool   `json:"default"`
 }
 
 type ParameterDefinitions struct {
-	DefaultParameterValue struct {
-		Name  string `json:"name"`
-		Value string `json:"value"`
-	} `json:"defaultParameterValue"`
-	Description string   `json:"description"`
-	Name        string   `json:"name"`
-	Type        string   `json:"type"`
-	Choices     []string `json:"choices,omitempty"`
+	Class                 string    `json:"_class,omitempty"`
+	DefaultParameterValue Parameter `json:"defaultParameterValue"`
+	Description           string    `json:"description"`
+	Name                  string    `json:"name"`
+	Type                  string    `json:"type"`
+	Choices               []string  `json:"choices,omitempty"`
+	// ProjectName is the job of a Run parameter
+	ProjectName string `json:"projectName,omitempty"`
 }
 
 type Env struct {
@@ -114,11 +179,37 @@ type Env struct {
 	Name   EName  `yaml:"name"`
 	Type   EType  `yaml:"type"`
 	Login  string `yaml:"login"`
-	Secret string `yaml:"secret"`
+	Secret string `yaml:"secret,omitempty"`
+	// SecretRef points to the secret kept outside of the config,
+	// e.g. "env:JENKINS_TOKEN", "command:pass show jenkins" or "file:dev"
+	SecretRef string `yaml:"secret_ref,omitempty"`
+	TLS       *TLS   `yaml:"tls,omitempty"`
+	// Concurrency limits parallel requests while the views and jobs
+	// are refreshed, 4 when not set
+	Concurrency int `yaml:"concurrency,omitempty"`
+	// Polling of running builds and queue items, see Polling
+	Polling *Polling `yaml:"polling,omitempty"`
+}
+
+func (e Env) Summary() EnvSummary {
+	return EnvSummary{
+		Name:      e.Name,
+		URL:       e.Url,
+		Type:      e.Type,
+		Login:     e.Login,
+		TLSVerify: e.TLS != nil && e.TLS.Verify,
+	}
+}
+
+// NeedsTLSMigration reports whether the environment was created before TLS
+// settings existed, such environments keep skipping certificate verification
+func (e Env) NeedsTLSMigration() bool {
+	return e.TLS == nil && strings.HasPrefix(strings.ToLower(e.Url), "https://")
 }
 
 type JobInfo struct {
 	Name               string `json:"name"`
+	FullName           string `json:"fullName"`
 	NextBuildNumber    int    `json:"nextBuildNumber"`
 	DownstreamProjects []struct {
 		Name string `json:"name"`
@@ -132,19 +223,18 @@ type JobInfo struct {
 		Number int    `json:"number"`
 		URL    string `json:"url"`
 	}
-	InQueue  bool `json:"inQueue"`
-	Property []struct {
+	InQueue bool `json:"inQueue"`
+	// LabelExpression restricts the nodes the job can run on
+	LabelExpression string `json:"labelExpression"`
+	Property        []struct {
 		ParameterDefinitions []ParameterDefinitions `json:"parameterDefinitions,omitempty"`
 	} `json:"property"`
 }
 
 type QueueInfo struct {
 	Actions []struct {
-		Parameters []struct {
-			Name  string `json:"name"`
-			Value string `json:"value"`
-		} `json:"parameters,omitempty"`
-		Causes []struct {
+		Parameters []Parameter `json:"parameters,omitempty"`
+		Causes     []struct {
 			ShortDescription string      `json:"shortDescription"`
 			UserID           interface{} `json:"userId"`
 			UserName         string      `json:"userName"`
@@ -172,34 +262,87 @@ type QueueInfo struct {
 
 type Queues struct {
 	DiscoverableItems []interface{} `json:"discoverableItems"`
-	Items             []struct {
-		Actions []struct {
-			Parameters []struct {
-				Name  string `json:"name"`
-				Value string `json:"value"`
-			} `json:"parameters,omitempty"`
-			Causes []struct {
-				ShortDescription string `json:"shortDescription"`
-				UpstreamBuild    int    `json:"upstreamBuild"`
-				UpstreamProject  string `json:"upstreamProject"`
-				UpstreamURL      string `json:"upstreamUrl"`
-			} `json:"causes,omitempty"`
-		} `json:"actions"`
-		Blocked                    bool   `json:"blocked"`
-		Buildable                  bool   `json:"buildable"`
-		BuildableStartMilliseconds int64  `json:"buildableStartMilliseconds"`
-		ID                         int    `json:"id"`
-		InQueueSince               int64  `json:"inQueueSince"`
-		Params                     string `json:"params"`
-		Stuck                      bool   `json:"stuck"`
-		Task                       struct {
-			Color string `json:"color"`
-			Name  string `json:"name"`
-			URL   string `json:"url"`
-		} `json:"task"`
-		URL string `json:"url"`
-		Why string `json:"why"`
-	} `json:"items"`
+	Items             []QueueItem   `json:"items"`
+}
+
+type QueueItem struct {
+	Actions []struct {
+		Parameters []Parameter `json:"parameters,omitempty"`
+		Causes     []struct {
+			ShortDescription string `json:"shortDescription"`
+			UpstreamBuild    int    `json:"upstreamBuild"`
+			UpstreamProject  string `json:"upstreamProject"`
+			UpstreamURL      string `json:"upstreamUrl"`
+			UserID           string `json:"userId"`
+			UserName         string `json:"userName"`
+		} `json:"causes,omitempty"`
+	} `json:"actions"`
+	Blocked                    bool   `json:"blocked"`
+	Buildable                  bool   `json:"buildable"`
+	BuildableStartMilliseconds int64  `json:"buildableStartMilliseconds"`
+	ID                         int    `json:"id"`
+	InQueueSince               int64  `json:"inQueueSince"`
+	Params                     string `json:"params"`
+	Stuck                      bool   `json:"stuck"`
+	Task                       struct {
+		Color string `json:"color"`
+		Name  string `json:"name"`
+		URL   string `json:"url"`
+	} `json:"task"`
+	URL string `json:"url"`
+	Why string `json:"why"`
+}
+
+// QueueSummary is an item of the build queue
+type QueueSummary struct {
+	ID           int         `json:"id"`
+	Job          string      `json:"job"`
+	Why          string      `json:"why"`
+	InQueueSince int64       `json:"inQueueSince"`
+	Blocked      bool        `json:"blocked"`
+	Stuck        bool        `json:"stuck"`
+	UserID       string      `json:"userId,omitempty"`
+	URL          string      `json:"url"`
+	Parameters   []Parameter `json:"parameters"`
+}
+
+// Job returns the full name of the queued job
+func (qi QueueItem) Job() string {
+	if name := ParseJobURL(qi.Task.URL); name != "" {
+		return string(name)
+	}
+	return qi.Task.Name
+}
+
+// UserID returns the id of the user who started the build, it is empty
+// for builds started by timers, upstream builds or SCM changes
+func (qi QueueItem) UserID() string {
+	for _, a := range qi.Actions {
+		for _, c := range a.Causes {
+			if c.UserID != "" {
+				return c.UserID
+			}
+		}
+	}
+	return ""
+}
+
+func (qi QueueItem) Summary() QueueSummary {
+	params := []Parameter{}
+	for _, a := range qi.Actions {
+		params = append(params, a.Parameters...)
+	}
+	return QueueSummary{
+		ID:           qi.ID,
+		Job:          qi.Job(),
+		Why:          qi.Why,
+		InQueueSince: qi.InQueueSince,
+		Blocked:      qi.Blocked,
+		Stuck:        qi.Stuck,
+		UserID:       qi.UserID(),
+		URL:          qi.URL,
+		Parameters:   params,
+	}
 }
 
 func initConfig() {
@@ -222,7 +365,7 @@ func initConfig() {
 			}
 		}
 		if changed {
-			SetConf()
+			saveConfig()
 			changed = false
 		} else {
 			break
@@ -232,134 +375,3 @@ func initConfig() {
 		log.Fatalf("error: %v", err)
 	}
 }
-
-func setViews(env Env, views []View) {
-	mutex.Lock()
-	defer mutex.Unlock()
-	bundle := GetBundle(env)
-	if bundle == nil {
-		bundle = &Bundle{Name: env.Name, Views: views, JobsInfo: []JobInfo{}}
-		bundles = append(bundles, bundle)
-	} else {
-		bundle.Views = views
-	}
-	updateCache(env, bundle)
-}
-
-func initBundle(env Env) {
-	var bundle Bundle
-	cachebin, err := ioutil.ReadFile(homeDir + cacheFile + "." + string(env.Name))
-	err = json.Unmarshal(cachebin, &bundle)
-	if err != nil {
-		fetchBundle(env, false)
-	} else {
-		mutex.Lock()
-		defer mutex.Unlock()
-		bundles = append(bundles, &bundle)
-		go fetchBundle(env, true)
-	}
-}
-
-func fetchBundle(env Env, async bool) {
-	code, rspbin, _, err := req(env, "POST", "api/json", []byte{})
-	if err != nil {
-		if !async {
-			fmt.Printf("error: %s\n", err)
-		}
-		return
-	}
-	if code != 200 {
-		if !async {
-			fmt.Println("failed to get job details:code " + strconv.Itoa(code) + " " + env.Login)
-		}
-
-		return
-	}
-	var rsp struct {
-		Views []View `json:"views"`
-	}
-	err = json.Unmarshal(rspbin, &rsp)
-	if err != nil {
-		panic(err)
-	}
-	for i, view := range rsp.Views {
-		code, vrspbin, _, err := req(env, "POST", "view/"+view.Name+"/api/json", []byte{})
-		if err != nil {
-			panic(err)
-		}
-		if code != 200 {
-			panic("failed to get view details,code" + strconv.Itoa(code) + ", " + string(rspbin))
-		}
-		err = json.Unmarshal(vrspbin, &rsp.Views[i])
-		if err != nil {
-			panic(err)
-		}
-	}
-	setViews(env, rsp.Views)
-
-}
-
-func updateCache(env Env, bundle *Bundle) {
-	cachebin, err := json.Marshal(bundle)
-	if err == nil {
-		path := homeDir + cacheFile + "." + string(env.Name)
-		ioutil.WriteFile(path, cachebin, 0644)
-	} else {
-		fmt.Println("failed to Marshal cache info, ", err.Error())
-	}
-}
-
-// RefreshBundle 强制同步刷新指定环境的 Jenkins 视图和任务缓存
-// 用于在新增或变更 Jenkins 任务后，立即拿到最新的数据
-func RefreshBundle(env Env) {
-	// 同步刷新，避免异步刷新导致短时间内依旧使用旧缓存
-	fetchBundle(env, false)
-}
-
-func reqPOST(env Env, method, path string, body []byte) (int, []byte, map[string][]string, error) {
-	return req(env, method, path, body)
-}
-
-func ReqPOST(env Env, method, path string, body []byte) (int, []byte, map[string][]string, error) {
-	return reqPOST(env, method, path, body)
-}
-
-func Req(env Env, method, path string, body []byte) (int, []byte, map[string][]string, error) {
-	return req(env, method, path, body)
-}
-
-func req(env Env, method, path string, body []byte) (int, []byte, map[string][]string, error) {
-	base_url := env.Url
-	if base_url[len(base_url)-1:] != "/" {
-		base_url = base_url + "/"
-	}
-
-	url := base_url + path
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-	}
-	client := &http.Client{Transport: tr, Timeout: time.Second * 30}
-	request, err := http.NewRequest(method, url, strings.NewReader(string(body)))
-	if err != nil {
-		return 0, nil, nil, err
-	}
-	request.Header.Add("Accept-Language", "en-us")
-	request.Header.Add("Content-Type", "application/x-www-form-urlencoded")
-	if env.Type == "a" {
-		request.SetBasicAuth(env.Login, env.Secret)
-	}
-	response, err := client.Do(request)
-	if err != nil {
-		return 0, nil, nil, err
-	}
-	defer response.Body.Close()
-	contents, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		return 0, nil, nil, err
-	}
-
-	if Debug {
-		fmt.Println("req: ", url, "\t data: ", string(body), "type:", response.Header.Get("Content-Type"), "\n rsp: ", string(contents))
-	}
-	return response.StatusCode, contents, response.Header, nil
-}
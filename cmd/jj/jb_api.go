@@ -1,32 +1,79 @@
 package jj
 
 import (
-	"encoding/json"
-	"errors"
+	"context"
 	"fmt"
 	"gopkg.in/yaml.v2"
+	"io"
 	"io/ioutil"
+	"log"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 // External API
+//
+// The functions below work with Jenkins from ~/.jj/config.yaml through a
+// shared Client per Jenkins name
 
-func GetBundle(env Env) *Bundle {
-	for _, b := range bundles {
-		if b.Name == env.Name {
-			return b
+var clients = map[EName]*Client{}
+var clientsMutex sync.Mutex
+
+func clientFor(env Env) *Client {
+	clientsMutex.Lock()
+	defer clientsMutex.Unlock()
+	c, ok := clients[env.Name]
+	if !ok || c.env != env {
+		loadConfig()
+		opts := []Option{WithCacheDir(homeDir)}
+		if Debug {
+			opts = append(opts, WithLogger(log.New(os.Stdout, "", 0)))
 		}
+		c = NewClient(env, opts...)
+		clients[env.Name] = c
 	}
-	return nil
+	return c
+}
+
+// GetClient returns the shared client of the Jenkins
+func GetClient(env Env) *Client {
+	return clientFor(env)
+}
+
+func GetBundle(env Env) *Bundle {
+	return clientFor(env).Bundle()
+}
+
+// RefreshBundle 强制同步刷新指定环境的 Jenkins 视图和任务缓存
+// 用于在新增或变更 Jenkins 任务后，立即拿到最新的数据
+func RefreshBundle(env Env) error {
+	// 同步刷新，避免异步刷新导致短时间内依旧使用旧缓存
+	return clientFor(env).RefreshBundle(context.Background())
+}
+
+func Req(env Env, method, path string, body []byte) (int, []byte, map[string][]string, error) {
+	return clientFor(env).Req(context.Background(), method, path, body)
+}
+
+func ReqPOST(env Env, method, path string, body []byte) (int, []byte, map[string][]string, error) {
+	return Req(env, method, path, body)
 }
 
 func DelEnv(name EName) error {
+	loadConfig()
 	for i, e := range config.Envs {
 		if e.Name == name {
 			config.Envs = append(config.Envs[:i], config.Envs[i+1:]...)
 			SetConf()
+			if err := DefaultPresetStore.DeleteEnv(name); err != nil {
+				return err
+			}
+			if strings.HasPrefix(e.SecretRef, "file:") {
+				return DefaultFileStore.Delete(strings.TrimPrefix(e.SecretRef, "file:"))
+			}
 			return nil
 		}
 	}
@@ -34,17 +81,11 @@ func DelEnv(name EName) error {
 }
 
 func Check(env Env) error {
-	code, rspbin, _, err := req(env, "GET", "api/json", []byte{})
-	if err != nil {
-		return err
-	}
-	if code != 200 {
-		return fmt.Errorf("http code: %d\nresponse: %s", code, rspbin)
-	}
-	return nil
+	return NewClient(env).Check(context.Background())
 }
 
 func GetEnvs() []Env {
+	loadConfig()
 	return config.Envs
 }
 
@@ -75,6 +116,7 @@ func (ji *JobInfo) GetParameterDefinitions() []ParameterDefinitions {
 }
 
 func GetDefEnv() EName {
+	loadConfig()
 	if config.Use == "" {
 		return GetEnvs()[0].Name
 	}
@@ -96,6 +138,11 @@ func SetDef(eName string) {
 }
 
 func SetConf() {
+	loadConfig()
+	saveConfig()
+}
+
+func saveConfig() {
 	out, _ := yaml.Marshal(config)
 	if _, err := os.Stat(homeDir); os.IsNotExist(err) {
 		err := os.MkdirAll(homeDir, os.ModePerm)
@@ -103,13 +150,18 @@ func SetConf() {
 			panic(err)
 		}
 	}
-	err := ioutil.WriteFile(homeDir+configFile, out, 0644)
+	// the config may hold plain text secrets of older versions
+	err := ioutil.WriteFile(homeDir+configFile, out, 0600)
+	if err == nil {
+		err = os.Chmod(homeDir+configFile, 0600)
+	}
 	if err != nil {
 		panic(err)
 	}
 }
 
 func SetEnv(env Env) {
+	loadConfig()
 	added := false
 	for i, e := range config.Envs {
 		if e.Name == env.Name {
@@ -141,46 +193,13 @@ func SetEnv(env Env) {
 //	return jobinfo
 //}
 func GetJobInfo(env Env, jobName string) (error, *JobInfo) {
-	bundle := GetBundle(env)
-	var jobInfo *JobInfo
-	for _, ji := range bundle.JobsInfo {
-		if ji.Name == jobName {
-			jobInfo = &ji
-			break
-		}
+	c := clientFor(env)
+	if jobInfo := c.CachedJobInfo(jobName); jobInfo != nil {
+		go c.GetJobInfo(context.Background(), jobName)
+		return nil, jobInfo
 	}
-	var fetchJobInfo = func() (error, JobInfo) {
-		var ji JobInfo
-		code, rsp, _, err := req(env, "POST", "job/"+jobName+"/api/json", []byte{})
-		if err != nil {
-			return err, ji
-		}
-		if code != 200 {
-			return ErrNoJob, ji
-		}
-		err = json.Unmarshal(rsp, &ji)
-		if err != nil {
-			panic("failed to get Job information")
-		}
-		mutex.Lock()
-		defer mutex.Unlock()
-		bundle.JobsInfo = append(bundle.JobsInfo, ji)
-		updateCache(env, bundle)
-		return nil, ji
-	}
-	if jobInfo != nil {
-		//fmt.Println("async")
-		go fetchJobInfo()
-	} else {
-		//fmt.Println("sync")
-		err, ji := fetchJobInfo()
-		if err != nil {
-			return err, jobInfo
-		}
-		jobInfo = &ji
-	}
-	return nil, jobInfo
-
+	jobInfo, err := c.GetJobInfo(context.Background(), jobName)
+	return err, jobInfo
 }
 
 //
@@ -218,114 +237,125 @@ func GetJobInfo(env Env, jobName string) (error, *JobInfo) {
 //
 
 func GetBuildInfo(env Env, job string, id int) (*BuildInfo, error) {
-	code, rsp, _, err := req(env, "POST", "job/"+job+"/"+strconv.Itoa(id)+"/api/json", []byte{})
-	if err != nil {
-		panic(err)
-	}
-	if code != 200 {
-		return nil, errors.New("failed to get job details,code" + strconv.Itoa(code) + ", " + string(rsp))
-	}
-	var bi BuildInfo
-	err = json.Unmarshal(rsp, &bi)
-	if err != nil {
-		return nil, err
-	}
-	return &bi, nil
+	return clientFor(env).GetBuildInfo(context.Background(), job, id)
 }
 
 func GetLastSuccessfulBuildInfo(env Env, job string) (*BuildInfo, error) {
-	code, rsp, _, err := req(env, "POST", "job/"+job+"/lastSuccessfulBuild/api/json", []byte{})
-	if err != nil {
-		panic(err)
-	}
-	if code != 200 {
-		return nil, errors.New("failed to get job details,code" + strconv.Itoa(code) + ", " + string(rsp))
-	}
-	var bi BuildInfo
-	err = json.Unmarshal(rsp, &bi)
-	if err != nil {
-		return nil, err
-	}
-	return &bi, nil
+	return clientFor(env).GetLastSuccessfulBuildInfo(context.Background(), job)
 }
 
 func Build(env Env, job string, query string) (error, string) {
-	target := "/build"
-	if len(query) > 0 {
-		target = "/buildWithParameters?" + query
-	}
-	code, rsp, headers, err := req(env, "POST", "job/"+job+target, []byte{})
+	id, err := clientFor(env).Build(context.Background(), job, query)
 	if err != nil {
 		return err, ""
 	}
-	if code != 201 {
-		return errors.New("failed to start job details,code" + strconv.Itoa(code) + ", " + string(rsp)), ""
-	}
-	location := headers["Location"][0]
-	splitedUrl := strings.Split(location, "/")
-	return nil, splitedUrl[len(splitedUrl)-2]
+	return nil, strconv.Itoa(id)
+}
+
+func BuildWithFiles(env Env, job string, values url.Values, files map[string]string) (int, error) {
+	return clientFor(env).BuildWithFiles(context.Background(), job, values, files)
+}
+
+func GitParameterValues(env Env, job string, param string) ([]string, error) {
+	return clientFor(env).GitParameterValues(context.Background(), job, param)
+}
 
+func GetLastBuildInfo(env Env, job string) (*BuildInfo, error) {
+	return clientFor(env).GetLastBuildInfo(context.Background(), job)
 }
 
-func CancelQueue(env Env, id int) {
-	req(env, "POST", "queue/cancelItem?id="+strconv.Itoa(id), []byte{})
+func CancelQueue(env Env, id int) error {
+	return clientFor(env).CancelQueue(context.Background(), id)
 }
+
+// CancelJob asks Jenkins to abort the build gracefully
 func CancelJob(env Env, job string, id int) (string, error) {
-	code, _, _, err := req(env, "POST", "job/"+job+"/"+strconv.Itoa(id)+"/stop", []byte{})
-	if err != nil {
-		panic(err)
-	}
-	if code != 200 {
-		return "", errors.New("failed to cancel the job,code" + strconv.Itoa(code))
-	}
-	bi, err := GetBuildInfo(env, job, id)
-	if err != nil {
-		return "", err
-	}
-	return bi.Result, nil
+	return clientFor(env).CancelJob(context.Background(), job, id)
+}
+
+// TermJob forcibly terminates a build which ignored the stop request
+func TermJob(env Env, job string, id int) (string, error) {
+	return clientFor(env).TermJob(context.Background(), job, id)
+}
+
+// KillJob hard-kills a build, it is the last resort when even term did not help
+func KillJob(env Env, job string, id int) (string, error) {
+	return clientFor(env).KillJob(context.Background(), job, id)
 }
 
 func Console(env Env, job string, id int, start string) (string, string, error) {
 	//web-rpm-build-manual/149/logText/progressiveHtml
-	code, rsp, h, err := req(env, "POST", "job/"+job+"/"+strconv.Itoa(id)+"/logText/progressiveHtml", []byte("start="+start))
-	if err != nil {
-		return "", "", err
-	}
-	if code != 200 {
-		return "", "", errors.New("code = " + strconv.Itoa(code))
-	}
-	//fmt.Println(h)
-	return string(rsp), h["X-Text-Size"][0], nil
+	return clientFor(env).Console(context.Background(), job, id, start)
+}
+
+func GetBuilds(env Env, job string) ([]BuildSummary, error) {
+	return clientFor(env).GetBuilds(context.Background(), job)
+}
+
+func GetConsolePart(env Env, job string, id int, start string) (*ConsolePart, error) {
+	return clientFor(env).GetConsolePart(context.Background(), job, id, start)
+}
+
+func StreamConsole(env Env, job string, id int, start int64, w io.Writer) (*LogPart, error) {
+	return clientFor(env).StreamConsole(context.Background(), job, id, start, w)
+}
+
+func StreamTimestamps(env Env, job string, id int, startLine int, w io.Writer) error {
+	return clientFor(env).StreamTimestamps(context.Background(), job, id, startLine, w)
+}
+
+func GetArtifacts(env Env, job string, id int) ([]Artifact, error) {
+	return clientFor(env).GetArtifacts(context.Background(), job, id)
+}
+
+func DownloadArtifacts(env Env, job string, id int, artifacts []Artifact, dir string, done func(a Artifact, err error)) error {
+	return clientFor(env).DownloadArtifacts(context.Background(), job, id, artifacts, dir, done)
+}
+
+func DownloadArchive(env Env, job string, id int, file string) error {
+	return clientFor(env).DownloadArchive(context.Background(), job, id, file)
+}
+
+func GetTestReport(env Env, job string, id int) (*TestReport, error) {
+	return clientFor(env).GetTestReport(context.Background(), job, id)
+}
+
+func DescribeRun(env Env, job string, id int) (*PipelineRun, error) {
+	return clientFor(env).DescribeRun(context.Background(), job, id)
+}
+
+func GetStageLog(env Env, job string, id int, stageID string) (string, error) {
+	return clientFor(env).GetStageLog(context.Background(), job, id, stageID)
+}
+
+func GetPendingInputs(env Env, job string, id int) ([]InputAction, error) {
+	return clientFor(env).GetPendingInputs(context.Background(), job, id)
+}
+
+func ProceedInput(env Env, job string, id int, input InputAction, values map[string]string) error {
+	return clientFor(env).ProceedInput(context.Background(), job, id, input, values)
+}
+
+func AbortInput(env Env, job string, id int, inputID string) error {
+	return clientFor(env).AbortInput(context.Background(), job, id, inputID)
+}
+
+func GetLabelLoad(env Env, label string) (*LabelLoad, error) {
+	return clientFor(env).GetLabelLoad(context.Background(), label)
 }
 
 func GetQueueInfo(env Env, id int) (error, QueueInfo) {
-	var queueInfo QueueInfo
-	code, rsp, _, err := req(env, "POST", "/queue/item/"+strconv.Itoa(id)+"/api/json", []byte{})
-	if err != nil {
-		panic(err)
-	}
-	if code != 200 {
-		return errors.New("failed to get queue details,code" + strconv.Itoa(code) + ", " + string(rsp)), QueueInfo{}
-	}
-	err = json.Unmarshal(rsp, &queueInfo)
+	queueInfo, err := clientFor(env).GetQueueInfo(context.Background(), id)
 	if err != nil {
-		return errors.New("failed to get Job information"), QueueInfo{}
+		return err, QueueInfo{}
 	}
-	return nil, queueInfo
+	return nil, *queueInfo
 }
 
-func GetQueues(env Env) Queues {
-	var queues Queues
-	code, rsp, _, err := req(env, "POST", "/queue/api/json", []byte{})
-	if err != nil {
-		panic(err)
-	}
-	if code != 200 {
-		panic("failed to get queue list,code" + strconv.Itoa(code) + ", " + string(rsp))
-	}
-	err = json.Unmarshal(rsp, &queues)
+func GetQueues(env Env) (Queues, error) {
+	queues, err := clientFor(env).GetQueues(context.Background())
 	if err != nil {
-		panic("failed to get Queue list")
+		return Queues{}, err
 	}
-	return queues
+	return *queues, nil
 }
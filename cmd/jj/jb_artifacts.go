@@ -0,0 +1,213 @@
+package jj
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Artifact is a file archived by a build
+type Artifact struct {
+	FileName     string `json:"fileName"`
+	RelativePath string `json:"relativePath"`
+	// Size is not a part of the build api, it is filled in by GetArtifacts,
+	// -1 when the Jenkins doesn't tell it
+	Size int64 `json:"size,omitempty"`
+}
+
+func artifactURL(job string, id int, relativePath string) string {
+	segments := strings.Split(relativePath, "/")
+	for i := range segments {
+		segments[i] = url.PathEscape(segments[i])
+	}
+	return JobPath(job).URL() + "/" + strconv.Itoa(id) + "/artifact/" + strings.Join(segments, "/")
+}
+
+// GetArtifacts returns artifacts of the build, sizes are read by HEAD
+// requests sent in parallel
+func (c *Client) GetArtifacts(ctx context.Context, job string, id int) ([]Artifact, error) {
+	var bi BuildInfo
+	path := JobPath(job).URL() + "/" + strconv.Itoa(id) + "/api/json?tree=artifacts[fileName,relativePath]"
+	if err := c.reqJSON(ctx, "POST", path, &bi); err != nil {
+		return nil, err
+	}
+	artifacts := bi.Artifacts
+	if artifacts == nil {
+		artifacts = []Artifact{}
+	}
+	err := c.parallel(len(artifacts), func(i int) error {
+		size, err := c.contentLength(ctx, artifactURL(job, id, artifacts[i].RelativePath))
+		artifacts[i].Size = size
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return artifacts, nil
+}
+
+func (c *Client) contentLength(ctx context.Context, path string) (int64, error) {
+	if err := c.waitThrottle(ctx); err != nil {
+		return 0, &Error{Kind: ErrNetwork, URL: c.url(path), Err: err}
+	}
+	response, err := c.send(ctx, "HEAD", path, formContentType, nil, false, nil)
+	if err != nil {
+		return 0, err
+	}
+	response.Body.Close()
+	if response.StatusCode != 200 {
+		return 0, httpError(response.StatusCode, c.url(path))
+	}
+	return response.ContentLength, nil
+}
+
+// DownloadArtifacts saves the artifacts into dir keeping their relative paths,
+// c.concurrency files at once. Files of the same size are skipped and an
+// interrupted download goes on from the .part file it has left. done is
+// called after every artifact, possibly from several goroutines
+func (c *Client) DownloadArtifacts(ctx context.Context, job string, id int, artifacts []Artifact, dir string, done func(a Artifact, err error)) error {
+	return c.parallel(len(artifacts), func(i int) error {
+		a := artifacts[i]
+		file, err := artifactFile(dir, a.RelativePath)
+		if err == nil {
+			if fi, statErr := os.Stat(file); statErr != nil || a.Size < 0 || fi.Size() != a.Size {
+				err = c.download(ctx, artifactURL(job, id, a.RelativePath), file, a.Size)
+			}
+		}
+		if done != nil {
+			done(a, err)
+		}
+		return err
+	})
+}
+
+// DownloadArchive saves all artifacts of the build zipped by the Jenkins
+func (c *Client) DownloadArchive(ctx context.Context, job string, id int, file string) error {
+	return c.download(ctx, JobPath(job).URL()+"/"+strconv.Itoa(id)+"/artifact/*zip*/archive.zip", file, -1)
+}
+
+// artifactFile returns the local path of the artifact, relative paths are
+// given by the Jenkins and must not point outside of dir
+func artifactFile(dir, relativePath string) (string, error) {
+	file := filepath.Join(dir, filepath.FromSlash(relativePath))
+	rel, err := filepath.Rel(dir, file)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("artifact %s is outside of %s", relativePath, dir)
+	}
+	return file, nil
+}
+
+// download writes the body into file.part, requesting only the missing bytes
+// when the part exists, and renames it to file when it is complete. size is
+// checked when it is known (not negative), a part left by another file is
+// downloaded again from the start
+func (c *Client) download(ctx context.Context, path, file string, size int64) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	part := file + ".part"
+	written, resumed, err := c.downloadPart(ctx, path, part, true)
+	if resumed && (errors.Is(err, errStalePart) || err == nil && size >= 0 && written != size) {
+		c.logf("download: %s doesn't match %s, starting over", part, c.url(path))
+		written, _, err = c.downloadPart(ctx, path, part, false)
+	}
+	if err != nil {
+		return err
+	}
+	if size >= 0 && written != size {
+		return fmt.Errorf("%s has %d bytes instead of %d", c.url(path), written, size)
+	}
+	c.logf("download: %s", c.url(path))
+	return os.Rename(part, file)
+}
+
+// errStalePart is returned when the Jenkins sends another range than the
+// one following the part
+var errStalePart = errors.New("the part doesn't match the file")
+
+// downloadPart writes the body into part, resuming it when resume is set,
+// and returns the size of the part and whether it has been resumed
+func (c *Client) downloadPart(ctx context.Context, path, part string, resume bool) (int64, bool, error) {
+	flags := os.O_CREATE | os.O_WRONLY
+	if !resume {
+		flags |= os.O_TRUNC
+	}
+	f, err := os.OpenFile(part, flags, 0644)
+	if err != nil {
+		return 0, false, err
+	}
+	defer f.Close()
+	offset, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, false, err
+	}
+	header := http.Header{}
+	if offset > 0 {
+		header.Set("Range", "bytes="+strconv.FormatInt(offset, 10)+"-")
+	}
+	if err := c.waitThrottle(ctx); err != nil {
+		return 0, false, &Error{Kind: ErrNetwork, URL: c.url(path), Err: err}
+	}
+	response, err := c.send(ctx, "GET", path, formContentType, nil, false, header)
+	if err != nil {
+		return 0, false, err
+	}
+	defer response.Body.Close()
+	resumed := false
+	switch response.StatusCode {
+	case 200, 206:
+		if response.StatusCode == 206 {
+			if rangeStart(response.Header.Get("Content-Range")) != offset {
+				return 0, true, errStalePart
+			}
+			resumed = offset > 0
+		} else if offset > 0 {
+			// ranges are not supported, start over
+			if err := f.Truncate(0); err != nil {
+				return 0, false, err
+			}
+			if _, err := f.Seek(0, io.SeekStart); err != nil {
+				return 0, false, err
+			}
+		}
+		if _, err := io.Copy(f, response.Body); err != nil {
+			return 0, resumed, &Error{Kind: ErrNetwork, Code: response.StatusCode, URL: c.url(path), Err: err}
+		}
+	case 416:
+		// the part has been downloaded completely, or it is longer than the file
+		resumed = true
+	default:
+		if response.StatusCode == 429 || response.StatusCode == 503 {
+			c.throttle(parseRetryAfter(response.Header, time.Now()))
+		}
+		return 0, false, httpError(response.StatusCode, c.url(path))
+	}
+	written, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, resumed, err
+	}
+	return written, resumed, f.Close()
+}
+
+// rangeStart returns the first byte of "bytes START-END/SIZE", -1 when the
+// header is malformed
+func rangeStart(contentRange string) int64 {
+	r := strings.TrimPrefix(contentRange, "bytes ")
+	i := strings.Index(r, "-")
+	if i < 0 {
+		return -1
+	}
+	start, err := strconv.ParseInt(r[:i], 10, 64)
+	if err != nil {
+		return -1
+	}
+	return start
+}
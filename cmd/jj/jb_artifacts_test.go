@@ -0,0 +1,118 @@
+package jj_test
+
+import (
+	"archive/zip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj"
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj/jjtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArtifacts(t *testing.T) {
+	srv := jjtest.New()
+	defer srv.Close()
+	srv.AddJob("app").Artifacts = map[string]string{
+		"target/app.jar":          "jar contents",
+		"target/docs/index.html":  "<html>",
+		"reports/test report.xml": "<testsuite/>",
+	}
+
+	c := newClient(t, srv)
+	queueId, err := c.Build(ctx, "app", "")
+	assert.NoError(t, err)
+	_, err = c.GetQueueInfo(ctx, queueId)
+	assert.NoError(t, err)
+
+	artifacts, err := c.GetArtifacts(ctx, "app", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []jj.Artifact{
+		{FileName: "test report.xml", RelativePath: "reports/test report.xml", Size: 12},
+		{FileName: "app.jar", RelativePath: "target/app.jar", Size: 12},
+		{FileName: "index.html", RelativePath: "target/docs/index.html", Size: 6},
+	}, artifacts)
+
+	dir, err := ioutil.TempDir("", "jj")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	// an interrupted download, only the rest of the file is requested
+	jar := filepath.Join(dir, "target", "app.jar")
+	assert.NoError(t, os.MkdirAll(filepath.Dir(jar), 0755))
+	assert.NoError(t, ioutil.WriteFile(jar+".part", []byte("JAR "), 0644))
+
+	// the callback is called from several goroutines
+	var mutex sync.Mutex
+	done := []string{}
+	err = c.DownloadArtifacts(ctx, "app", 1, artifacts, dir, func(a jj.Artifact, err error) {
+		assert.NoError(t, err)
+		mutex.Lock()
+		done = append(done, a.RelativePath)
+		mutex.Unlock()
+	})
+	assert.NoError(t, err)
+	assert.Len(t, done, 3)
+	bin, err := ioutil.ReadFile(jar)
+	assert.NoError(t, err)
+	assert.Equal(t, "JAR contents", string(bin))
+	_, err = os.Stat(jar + ".part")
+	assert.True(t, os.IsNotExist(err))
+	bin, err = ioutil.ReadFile(filepath.Join(dir, "reports", "test report.xml"))
+	assert.NoError(t, err)
+	assert.Equal(t, "<testsuite/>", string(bin))
+
+	// files of the same size are not downloaded again
+	requests := srv.RequestCount("/job/app/1/artifact/target/app.jar")
+	assert.Equal(t, 2, requests, "HEAD and GET")
+	assert.NoError(t, c.DownloadArtifacts(ctx, "app", 1, artifacts, dir, nil))
+	assert.Equal(t, requests, srv.RequestCount("/job/app/1/artifact/target/app.jar"))
+
+	err = c.DownloadArtifacts(ctx, "app", 1, []jj.Artifact{{RelativePath: "../evil"}}, dir, nil)
+	assert.Error(t, err)
+
+	// a part left by another build is downloaded again
+	assert.NoError(t, os.Remove(jar))
+	assert.NoError(t, ioutil.WriteFile(jar+".part", []byte("contents of an older jar"), 0644))
+	assert.NoError(t, c.DownloadArtifacts(ctx, "app", 1, artifacts[1:2], dir, nil))
+	bin, err = ioutil.ReadFile(jar)
+	assert.NoError(t, err)
+	assert.Equal(t, "jar contents", string(bin))
+
+	file := filepath.Join(dir, "archive.zip")
+	assert.NoError(t, c.DownloadArchive(ctx, "app", 1, file))
+	zr, err := zip.OpenReader(file)
+	assert.NoError(t, err)
+	defer zr.Close()
+	assert.Len(t, zr.File, 3)
+	assert.Equal(t, "archive/reports/test report.xml", zr.File[0].Name)
+}
+
+func TestDownloadStalePart(t *testing.T) {
+	// the server ignores the start of the range
+	hs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Range") != "" {
+			w.Header().Set("Content-Range", "bytes 0-11/12")
+			w.WriteHeader(206)
+		}
+		io.WriteString(w, "jar contents")
+	}))
+	defer hs.Close()
+	c := jj.NewClient(jj.Env{Name: "stale-part", Url: hs.URL})
+
+	dir, err := ioutil.TempDir("", "jj")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	jar := filepath.Join(dir, "app.jar")
+	assert.NoError(t, ioutil.WriteFile(jar+".part", []byte("JAR "), 0644))
+	artifacts := []jj.Artifact{{FileName: "app.jar", RelativePath: "app.jar", Size: 12}}
+	assert.NoError(t, c.DownloadArtifacts(ctx, "app", 1, artifacts, dir, nil))
+	bin, err := ioutil.ReadFile(jar)
+	assert.NoError(t, err)
+	assert.Equal(t, "jar contents", string(bin))
+}
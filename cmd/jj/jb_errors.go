@@ -0,0 +1,68 @@
+package jj
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kinds of failures, use errors.Is to check which one has happened
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrServer       = errors.New("server error")
+	ErrTooMany      = errors.New("too many requests")
+	ErrNetwork      = errors.New("network error")
+	ErrDecode       = errors.New("failed to decode response")
+	ErrUnexpected   = errors.New("unexpected response")
+)
+
+// Error describes a failed request to the Jenkins
+type Error struct {
+	Kind error
+	Code int
+	URL  string
+	Err  error
+}
+
+func (e *Error) Error() string {
+	msg := e.Kind.Error()
+	if e.Code != 0 {
+		msg = fmt.Sprintf("%s (code %d)", msg, e.Code)
+	}
+	msg = msg + ": " + e.URL
+	if e.Err != nil {
+		msg = msg + ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) Is(target error) bool {
+	return e.Kind == target
+}
+
+// IsTemporary reports whether the request is worth to be retried
+func IsTemporary(err error) bool {
+	return errors.Is(err, ErrNetwork) || errors.Is(err, ErrServer) || errors.Is(err, ErrTooMany)
+}
+
+func httpError(code int, url string) error {
+	kind := ErrUnexpected
+	switch {
+	case code == 401:
+		kind = ErrUnauthorized
+	case code == 403:
+		kind = ErrForbidden
+	case code == 404:
+		kind = ErrNotFound
+	case code == 429:
+		kind = ErrTooMany
+	case code >= 500:
+		kind = ErrServer
+	}
+	return &Error{Kind: kind, Code: code, URL: url}
+}
@@ -0,0 +1,42 @@
+package jj
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHttpError(t *testing.T) {
+	tcases := []struct {
+		code int
+		kind error
+	}{
+		{401, ErrUnauthorized},
+		{403, ErrForbidden},
+		{404, ErrNotFound},
+		{500, ErrServer},
+		{503, ErrServer},
+		{429, ErrTooMany},
+		{400, ErrUnexpected},
+	}
+	for _, tc := range tcases {
+		err := httpError(tc.code, "http://jenkins/job/app/api/json")
+		assert.True(t, errors.Is(err, tc.kind), "code %d", tc.code)
+		var jerr *Error
+		assert.True(t, errors.As(err, &jerr))
+		assert.Equal(t, tc.code, jerr.Code)
+		assert.Equal(t, "http://jenkins/job/app/api/json", jerr.URL)
+	}
+	assert.True(t, errors.Is(httpError(404, ""), ErrNoJob))
+}
+
+func TestIsTemporary(t *testing.T) {
+	assert.True(t, IsTemporary(&Error{Kind: ErrNetwork, Err: io.EOF}))
+	assert.True(t, IsTemporary(httpError(502, "")))
+	assert.True(t, IsTemporary(httpError(429, "")))
+	assert.False(t, IsTemporary(httpError(404, "")))
+	assert.False(t, IsTemporary(&Error{Kind: ErrDecode}))
+	assert.False(t, IsTemporary(io.EOF))
+}
@@ -0,0 +1,100 @@
+package jj
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// InputAction is an input step a Pipeline build is paused on
+type InputAction struct {
+	ID          string           `json:"id"`
+	Message     string           `json:"message"`
+	ProceedText string           `json:"proceedText"`
+	Inputs      []InputParameter `json:"inputs"`
+}
+
+// InputParameter is a parameter asked by an input step
+type InputParameter struct {
+	Type        string                 `json:"type"`
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Definition  map[string]interface{} `json:"definition"`
+}
+
+// ParameterDefinition returns the input as a parameter of a job, so it
+// can be asked and checked the same way
+func (p InputParameter) ParameterDefinition() ParameterDefinitions {
+	pd := ParameterDefinitions{Name: p.Name, Type: p.Type, Description: p.Description}
+	if choices, ok := p.Definition["choices"].([]interface{}); ok {
+		for _, c := range choices {
+			pd.Choices = append(pd.Choices, fmt.Sprint(c))
+		}
+	}
+	for _, key := range []string{"defaultVal", "defaultValue"} {
+		if v, ok := p.Definition[key]; ok && v != nil {
+			pd.DefaultParameterValue.Value = fmt.Sprint(v)
+		}
+	}
+	// like Jenkins, the first choice is the default one
+	if pd.DefaultParameterValue.Value == "" && len(pd.Choices) > 0 {
+		pd.DefaultParameterValue.Value = pd.Choices[0]
+	}
+	return pd
+}
+
+// GetPendingInputs returns input steps the build waits for, it fails with
+// ErrNotFound when the job is not a Pipeline
+func (c *Client) GetPendingInputs(ctx context.Context, job string, id int) ([]InputAction, error) {
+	inputs := []InputAction{}
+	if err := c.reqJSON(ctx, "GET", JobPath(job).URL()+"/"+strconv.Itoa(id)+"/wfapi/pendingInputActions", &inputs); err != nil {
+		return nil, err
+	}
+	return inputs, nil
+}
+
+// ProceedInput submits the input with the values of its parameters, Jenkins
+// doesn't use the defaults of the input step for missing values, they are
+// left out of the result
+func (c *Client) ProceedInput(ctx context.Context, job string, id int, input InputAction, values map[string]string) error {
+	base := JobPath(job).URL() + "/" + strconv.Itoa(id)
+	if len(input.Inputs) == 0 {
+		return c.postInput(ctx, base+"/input/"+url.PathEscape(input.ID)+"/proceedEmpty", nil)
+	}
+	parameter := []map[string]interface{}{}
+	for _, p := range input.Inputs {
+		val, ok := values[p.Name]
+		if !ok {
+			continue
+		}
+		var v interface{} = val
+		if p.ParameterDefinition().Kind() == ParamBool {
+			v = val == "true"
+		}
+		parameter = append(parameter, map[string]interface{}{"name": p.Name, "value": v})
+	}
+	body, err := json.Marshal(map[string]interface{}{"parameter": parameter})
+	if err != nil {
+		return err
+	}
+	form := url.Values{"json": {string(body)}}
+	return c.postInput(ctx, base+"/wfapi/inputSubmit?inputId="+url.QueryEscape(input.ID), []byte(form.Encode()))
+}
+
+func (c *Client) AbortInput(ctx context.Context, job string, id int, inputID string) error {
+	return c.postInput(ctx, JobPath(job).URL()+"/"+strconv.Itoa(id)+"/input/"+url.PathEscape(inputID)+"/abort", nil)
+}
+
+func (c *Client) postInput(ctx context.Context, path string, body []byte) error {
+	code, _, _, err := c.Req(ctx, "POST", path, body)
+	if err != nil {
+		return err
+	}
+	// the Jenkins redirects to the build after the input is submitted
+	if code >= 400 {
+		return httpError(code, c.url(path))
+	}
+	return nil
+}
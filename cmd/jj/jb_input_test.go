@@ -0,0 +1,54 @@
+package jj_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj"
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj/jjtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInput(t *testing.T) {
+	srv := jjtest.New()
+	defer srv.Close()
+	confirm := jj.ParameterDefinitions{Name: "CONFIRM", Type: "BooleanParameterDefinition"}
+	target := jj.ParameterDefinitions{Name: "TARGET", Type: "ChoiceParameterDefinition", Choices: []string{"uat", "prod"}}
+	job := srv.AddJob("deploy")
+	job.Stages = []jjtest.Stage{{Name: "Deploy"}}
+	job.Input = &jjtest.Input{ID: "Approve", Message: "Deploy?", Params: []jj.ParameterDefinitions{confirm, target}}
+	srv.AddJob("freestyle")
+
+	c := newClient(t, srv)
+	for _, name := range []string{"deploy", "deploy", "freestyle"} {
+		queueId, err := c.Build(ctx, name, "")
+		assert.NoError(t, err)
+		_, err = c.GetQueueInfo(ctx, queueId)
+		assert.NoError(t, err)
+	}
+
+	run, err := c.DescribeRun(ctx, "deploy", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, jj.StagePaused, run.Status)
+	inputs, err := c.GetPendingInputs(ctx, "deploy", 1)
+	assert.NoError(t, err)
+	assert.Len(t, inputs, 1)
+	assert.Equal(t, "Deploy?", inputs[0].Message)
+	assert.Equal(t, jj.ParamBool, inputs[0].Inputs[0].ParameterDefinition().Kind())
+	assert.Equal(t, []string{"uat", "prod"}, inputs[0].Inputs[1].ParameterDefinition().Choices)
+	assert.Equal(t, "uat", inputs[0].Inputs[1].ParameterDefinition().DefaultParameterValue.Value)
+
+	assert.NoError(t, c.ProceedInput(ctx, "deploy", 1, inputs[0], map[string]string{"CONFIRM": "true", "TARGET": "prod"}))
+	b := srv.Builds("deploy")[0]
+	assert.Equal(t, "SUCCESS", b.Result)
+	assert.Equal(t, map[string]interface{}{"CONFIRM": true, "TARGET": "prod"}, b.InputValues)
+	inputs, err = c.GetPendingInputs(ctx, "deploy", 1)
+	assert.NoError(t, err)
+	assert.Len(t, inputs, 0)
+
+	assert.NoError(t, c.AbortInput(ctx, "deploy", 2, "Approve"))
+	assert.Equal(t, "ABORTED", srv.Builds("deploy")[1].Result)
+
+	_, err = c.GetPendingInputs(ctx, "freestyle", 1)
+	assert.True(t, errors.Is(err, jj.ErrNotFound))
+}
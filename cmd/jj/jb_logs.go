@@ -0,0 +1,51 @@
+package jj
+
+import (
+	"context"
+	"io"
+	"strconv"
+)
+
+// LogPart describes a piece of the plain text log written by StreamConsole
+type LogPart struct {
+	// Next is the byte offset of the following part
+	Next int64
+	// More is set while Jenkins is still writing the log
+	More bool
+}
+
+// StreamConsole writes the plain text log of the build starting from the
+// byte offset to w without keeping it in memory
+func (c *Client) StreamConsole(ctx context.Context, job string, id int, start int64, w io.Writer) (*LogPart, error) {
+	path := JobPath(job).URL() + "/" + strconv.Itoa(id) + "/logText/progressiveText?start=" + strconv.FormatInt(start, 10)
+	code, h, err := c.Stream(ctx, path, w)
+	if err != nil {
+		return nil, err
+	}
+	if code != 200 {
+		return nil, httpError(code, c.url(path))
+	}
+	next, err := strconv.ParseInt(h.Get("X-Text-Size"), 10, 64)
+	if err != nil {
+		next = start
+	}
+	return &LogPart{Next: next, More: h.Get("X-More-Data") == "true"}, nil
+}
+
+// StreamTimestamps writes lines of the log prefixed with the time they were
+// written at, it needs the Timestamper plugin and fails with ErrNotFound
+// without it. A negative startLine counts lines from the end of the log
+func (c *Client) StreamTimestamps(ctx context.Context, job string, id int, startLine int, w io.Writer) error {
+	path := JobPath(job).URL() + "/" + strconv.Itoa(id) + "/timestamps/?time=HH:mm:ss&appendLog"
+	if startLine != 0 {
+		path += "&startLine=" + strconv.Itoa(startLine)
+	}
+	code, _, err := c.Stream(ctx, path, w)
+	if err != nil {
+		return err
+	}
+	if code != 200 {
+		return httpError(code, c.url(path))
+	}
+	return nil
+}
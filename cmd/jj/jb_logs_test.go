@@ -0,0 +1,62 @@
+package jj_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj"
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj/jjtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamConsole(t *testing.T) {
+	srv := jjtest.New()
+	defer srv.Close()
+	srv.AddJob("app").Script = jjtest.Script{Log: []string{"line 1\n", "line 2\n"}}
+
+	c := newClient(t, srv)
+	queueId, err := c.Build(ctx, "app", "")
+	assert.NoError(t, err)
+	_, err = c.GetQueueInfo(ctx, queueId)
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	part, err := c.StreamConsole(ctx, "app", 1, 0, &buf)
+	assert.NoError(t, err)
+	assert.Equal(t, &jj.LogPart{Next: 7, More: true}, part)
+	part, err = c.StreamConsole(ctx, "app", 1, part.Next, &buf)
+	assert.NoError(t, err)
+	assert.Equal(t, &jj.LogPart{Next: 14, More: false}, part)
+	assert.Equal(t, "line 1\nline 2\n", buf.String())
+
+	_, err = c.StreamConsole(ctx, "app", 2, 0, &buf)
+	assert.True(t, errors.Is(err, jj.ErrNotFound))
+}
+
+func TestStreamTimestamps(t *testing.T) {
+	srv := jjtest.New()
+	defer srv.Close()
+	job := srv.AddJob("app")
+	job.Script = jjtest.Script{Log: []string{"line 1\nline 2\nline 3\n"}}
+
+	c := newClient(t, srv)
+	queueId, err := c.Build(ctx, "app", "")
+	assert.NoError(t, err)
+	_, err = c.GetQueueInfo(ctx, queueId)
+	assert.NoError(t, err)
+	_, err = c.StreamConsole(ctx, "app", 1, 0, &bytes.Buffer{})
+	assert.NoError(t, err)
+
+	// the plugin is not installed
+	err = c.StreamTimestamps(ctx, "app", 1, 0, &bytes.Buffer{})
+	assert.True(t, errors.Is(err, jj.ErrNotFound))
+
+	job.Timestamps = true
+	var buf bytes.Buffer
+	assert.NoError(t, c.StreamTimestamps(ctx, "app", 1, 0, &buf))
+	assert.Equal(t, "00:00:01  line 1\n00:00:02  line 2\n00:00:03  line 3\n", buf.String())
+	buf.Reset()
+	assert.NoError(t, c.StreamTimestamps(ctx, "app", 1, -1, &buf))
+	assert.Equal(t, "00:00:03  line 3\n", buf.String())
+}
@@ -0,0 +1,33 @@
+package jj
+
+import (
+	"context"
+	"net/url"
+)
+
+// LabelLoad is the usage of executors of the nodes with a label
+type LabelLoad struct {
+	// Label is empty for all nodes of the Jenkins
+	Label string `json:"name"`
+	Idle  int    `json:"idleExecutors"`
+	Busy  int    `json:"busyExecutors"`
+	Total int    `json:"totalExecutors"`
+}
+
+// GetLabelLoad returns executors of the nodes matching the label,
+// all executors of the Jenkins when the label is empty
+func (c *Client) GetLabelLoad(ctx context.Context, label string) (*LabelLoad, error) {
+	var load LabelLoad
+	if label == "" {
+		if err := c.reqJSON(ctx, "POST", "computer/api/json?tree=busyExecutors,totalExecutors", &load); err != nil {
+			return nil, err
+		}
+		load.Idle = load.Total - load.Busy
+		return &load, nil
+	}
+	path := "label/" + url.PathEscape(label) + "/api/json?tree=name,idleExecutors,busyExecutors,totalExecutors"
+	if err := c.reqJSON(ctx, "POST", path, &load); err != nil {
+		return nil, err
+	}
+	return &load, nil
+}
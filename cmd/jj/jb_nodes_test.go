@@ -0,0 +1,29 @@
+package jj_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj"
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj/jjtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetLabelLoad(t *testing.T) {
+	srv := jjtest.New()
+	defer srv.Close()
+	srv.Executors[""] = jjtest.Executors{Idle: 3, Busy: 5}
+	srv.Executors["linux docker"] = jjtest.Executors{Idle: 0, Busy: 2}
+
+	c := newClient(t, srv)
+	load, err := c.GetLabelLoad(ctx, "")
+	assert.NoError(t, err)
+	assert.Equal(t, jj.LabelLoad{Idle: 3, Busy: 5, Total: 8}, *load)
+
+	load, err = c.GetLabelLoad(ctx, "linux docker")
+	assert.NoError(t, err)
+	assert.Equal(t, jj.LabelLoad{Label: "linux docker", Idle: 0, Busy: 2, Total: 2}, *load)
+
+	_, err = c.GetLabelLoad(ctx, "windows")
+	assert.True(t, errors.Is(err, jj.ErrNotFound))
+}
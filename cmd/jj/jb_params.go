@@ -0,0 +1,143 @@
+package jj
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ParamKind is the kind of a job parameter which defines how its value is
+// asked and submitted
+type ParamKind string
+
+const (
+	ParamString   ParamKind = "string"
+	ParamText     ParamKind = "text"
+	ParamBool     ParamKind = "boolean"
+	ParamPassword ParamKind = "password"
+	ParamChoice   ParamKind = "choice"
+	ParamFile     ParamKind = "file"
+	ParamRun      ParamKind = "run"
+	// ParamGit is a parameter of the Git Parameter plugin
+	ParamGit ParamKind = "git"
+)
+
+const gitParameterClass = "net.uaznia.lukanus.hudson.plugins.gitparameter.GitParameterDefinition"
+
+// UnmarshalJSON accepts values of any json type, booleans of Boolean
+// parameters become "true" and "false"
+func (p *Parameter) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		Name  string      `json:"name"`
+		Value interface{} `json:"value"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	p.Name = raw.Name
+	switch v := raw.Value.(type) {
+	case nil:
+		p.Value = ""
+	case string:
+		p.Value = v
+	default:
+		bin, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		p.Value = string(bin)
+	}
+	return nil
+}
+
+func (pd ParameterDefinitions) Kind() ParamKind {
+	switch {
+	case pd.Class == gitParameterClass || strings.HasPrefix(pd.Type, "PT_"):
+		return ParamGit
+	case pd.Type == "BooleanParameterDefinition":
+		return ParamBool
+	case pd.Type == "PasswordParameterDefinition":
+		return ParamPassword
+	case pd.Type == "TextParameterDefinition":
+		return ParamText
+	case pd.Type == "ChoiceParameterDefinition":
+		return ParamChoice
+	case pd.Type == "FileParameterDefinition":
+		return ParamFile
+	case pd.Type == "RunParameterDefinition":
+		return ParamRun
+	}
+	return ParamString
+}
+
+var runValue = regexp.MustCompile(`^(.+)#([0-9]+)$`)
+
+// Normalize checks the value against the definition and returns the value
+// in the form Jenkins expects. Values of Git parameters are checked by the
+// caller with GitParameterValues as it needs a request
+func (pd ParameterDefinitions) Normalize(value string) (string, error) {
+	switch pd.Kind() {
+	case ParamBool:
+		switch strings.ToLower(strings.TrimSpace(value)) {
+		case "true", "y", "yes", "1", "on":
+			return "true", nil
+		case "false", "n", "no", "0", "off", "":
+			return "false", nil
+		}
+		return "", fmt.Errorf("%s: '%s' is not a boolean", pd.Name, value)
+	case ParamChoice:
+		for _, c := range pd.Choices {
+			if c == value {
+				return value, nil
+			}
+		}
+		return "", fmt.Errorf("%s: '%s' is not one of %s", pd.Name, value, strings.Join(pd.Choices, ", "))
+	case ParamFile:
+		info, err := os.Stat(value)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", pd.Name, err)
+		}
+		if info.IsDir() {
+			return "", fmt.Errorf("%s: %s is a directory", pd.Name, value)
+		}
+		return value, nil
+	case ParamRun:
+		if _, err := strconv.Atoi(value); err == nil && pd.ProjectName != "" {
+			return pd.ProjectName + "#" + value, nil
+		}
+		m := runValue.FindStringSubmatch(value)
+		if m == nil {
+			return "", fmt.Errorf("%s: '%s' should look like JOB#NUMBER", pd.Name, value)
+		}
+		if pd.ProjectName != "" && m[1] != pd.ProjectName {
+			return "", fmt.Errorf("%s: the build should be of %s", pd.Name, pd.ProjectName)
+		}
+		return value, nil
+	}
+	return value, nil
+}
+
+// GitParameterValues returns branches, tags or revisions offered by the
+// Git Parameter plugin for the parameter of the job
+func (c *Client) GitParameterValues(ctx context.Context, job string, param string) ([]string, error) {
+	var rsp struct {
+		Values []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"values"`
+	}
+	path := JobPath(job).URL() + "/descriptorByName/" + gitParameterClass + "/fillValueItems?param=" + url.QueryEscape(param)
+	if err := c.reqJSON(ctx, "POST", path, &rsp); err != nil {
+		return nil, err
+	}
+	values := []string{}
+	for _, v := range rsp.Values {
+		values = append(values, v.Value)
+	}
+	return values, nil
+}
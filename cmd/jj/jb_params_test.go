@@ -0,0 +1,100 @@
+package jj_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj"
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj/jjtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParameterUnmarshal(t *testing.T) {
+	var ji jj.JobInfo
+	assert.NoError(t, json.Unmarshal([]byte(`{"property":[{"parameterDefinitions":[
+		{"name":"DEBUG","type":"BooleanParameterDefinition","defaultParameterValue":{"name":"DEBUG","value":true}},
+		{"name":"BRANCH","type":"PT_BRANCH","_class":"`+"net.uaznia.lukanus.hudson.plugins.gitparameter.GitParameterDefinition"+`","defaultParameterValue":{"value":"origin/master"}},
+		{"name":"SECRET","type":"PasswordParameterDefinition","defaultParameterValue":{"value":null}}
+	]}]}`), &ji))
+	params := ji.GetParameterDefinitions()
+	assert.Len(t, params, 3)
+	assert.Equal(t, jj.ParamBool, params[0].Kind())
+	assert.Equal(t, "true", params[0].DefaultParameterValue.Value)
+	assert.Equal(t, jj.ParamGit, params[1].Kind())
+	assert.Equal(t, jj.ParamPassword, params[2].Kind())
+	assert.Equal(t, "", params[2].DefaultParameterValue.Value)
+}
+
+func TestParameterNormalize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jj")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "app.tar")
+	assert.NoError(t, ioutil.WriteFile(file, []byte("app"), 0644))
+
+	tests := []struct {
+		pd    jj.ParameterDefinitions
+		value string
+		want  string
+	}{
+		{jj.ParameterDefinitions{Type: "BooleanParameterDefinition"}, "y", "true"},
+		{jj.ParameterDefinitions{Type: "BooleanParameterDefinition"}, "False", "false"},
+		{jj.ParameterDefinitions{Type: "BooleanParameterDefinition"}, "maybe", ""},
+		{jj.ParameterDefinitions{Type: "ChoiceParameterDefinition", Choices: []string{"uat", "prod"}}, "prod", "prod"},
+		{jj.ParameterDefinitions{Type: "ChoiceParameterDefinition", Choices: []string{"uat", "prod"}}, "dev", ""},
+		{jj.ParameterDefinitions{Type: "FileParameterDefinition"}, file, file},
+		{jj.ParameterDefinitions{Type: "FileParameterDefinition"}, dir, ""},
+		{jj.ParameterDefinitions{Type: "FileParameterDefinition"}, filepath.Join(dir, "missing"), ""},
+		{jj.ParameterDefinitions{Type: "RunParameterDefinition", ProjectName: "app"}, "12", "app#12"},
+		{jj.ParameterDefinitions{Type: "RunParameterDefinition", ProjectName: "app"}, "app#12", "app#12"},
+		{jj.ParameterDefinitions{Type: "RunParameterDefinition", ProjectName: "app"}, "web#12", ""},
+		{jj.ParameterDefinitions{Type: "RunParameterDefinition"}, "latest", ""},
+		{jj.ParameterDefinitions{Type: "TextParameterDefinition"}, "a\nb", "a\nb"},
+	}
+	for _, test := range tests {
+		got, err := test.pd.Normalize(test.value)
+		if test.want == "" {
+			assert.Error(t, err, test.value)
+			continue
+		}
+		assert.NoError(t, err, test.value)
+		assert.Equal(t, test.want, got)
+	}
+}
+
+func TestBuildWithFiles(t *testing.T) {
+	srv := jjtest.New()
+	defer srv.Close()
+	srv.AddJob("app")
+	dir, err := ioutil.TempDir("", "jj")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "app.tar")
+	assert.NoError(t, ioutil.WriteFile(file, []byte("content"), 0644))
+
+	c := newClient(t, srv)
+	queueId, err := c.BuildWithFiles(ctx, "app", url.Values{"BRANCH": {"master"}}, map[string]string{"PACKAGE": file})
+	assert.NoError(t, err)
+	_, err = c.GetQueueInfo(ctx, queueId)
+	assert.NoError(t, err)
+
+	builds := srv.Builds("app")
+	assert.Len(t, builds, 1)
+	assert.Equal(t, "master", builds[0].Params.Get("BRANCH"))
+	assert.Equal(t, map[string]string{"PACKAGE": "content"}, builds[0].Files)
+}
+
+func TestGitParameterValues(t *testing.T) {
+	srv := jjtest.New()
+	defer srv.Close()
+	srv.AddJob("app").GitValues = map[string][]string{"BRANCH": {"origin/master", "origin/develop"}}
+
+	c := newClient(t, srv)
+	values, err := c.GitParameterValues(ctx, "app", "BRANCH")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"origin/master", "origin/develop"}, values)
+}
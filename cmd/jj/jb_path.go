@@ -0,0 +1,66 @@
+package jj
+
+import (
+	"net/url"
+	"strings"
+)
+
+// JobPath is the full name of a job, segments of nested Folders or
+// Multibranch Pipelines are separated by slashes, e.g. "team/app/main"
+type JobPath string
+
+// URL returns the relative Jenkins url of the job, e.g. "job/team/job/app/job/main",
+// segments are escaped, so "app/feature%2Ffoo" becomes "job/app/job/feature%252Ffoo"
+func (p JobPath) URL() string {
+	segments := p.Segments()
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return "job/" + strings.Join(segments, "/job/")
+}
+
+func (p JobPath) Segments() []string {
+	return strings.Split(strings.Trim(string(p), "/"), "/")
+}
+
+// Name returns the short name of the job without parent folders
+func (p JobPath) Name() string {
+	segments := p.Segments()
+	return segments[len(segments)-1]
+}
+
+// Join returns the path of the child job inside the folder
+func (p JobPath) Join(name string) JobPath {
+	if p == "" {
+		return JobPath(name)
+	}
+	return JobPath(strings.Trim(string(p), "/") + "/" + name)
+}
+
+// MatchURL reports whether the absolute job url points to this job
+func (p JobPath) MatchURL(jobUrl string) bool {
+	return ParseJobURL(jobUrl) == p
+}
+
+// ParseJobURL extracts a job path from an absolute or relative job url,
+// e.g. "https://ci/job/team/job/app/" becomes "team/app". Jenkins encodes
+// slashes in job names (multibranch branches like "feature/foo"), an escaped
+// slash stays "%2F" in the name so it doesn't split the path
+func ParseJobURL(jobUrl string) JobPath {
+	if u, err := url.Parse(jobUrl); err == nil {
+		jobUrl = u.EscapedPath()
+	}
+	segments := strings.Split(strings.Trim(jobUrl, "/"), "/")
+	names := []string{}
+	for i := 0; i < len(segments)-1; i++ {
+		if segments[i] == "job" {
+			name, err := url.PathUnescape(segments[i+1])
+			if err != nil {
+				name = segments[i+1]
+			}
+			names = append(names, strings.ReplaceAll(name, "/", "%2F"))
+			i++
+		}
+	}
+	return JobPath(strings.Join(names, "/"))
+}
@@ -0,0 +1,55 @@
+package jj
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobPath(t *testing.T) {
+	tcases := []struct {
+		path JobPath
+		url  string
+		name string
+	}{
+		{"app", "job/app", "app"},
+		{"team/app", "job/team/job/app", "app"},
+		{"team/app/main", "job/team/job/app/job/main", "main"},
+		{"/team/app/", "job/team/job/app", "app"},
+		{"app/feature%2Ffoo", "job/app/job/feature%252Ffoo", "feature%2Ffoo"},
+		{"team/my app #2", "job/team/job/my%20app%20%232", "my app #2"},
+	}
+	for _, tc := range tcases {
+		t.Run(string(tc.path), func(t *testing.T) {
+			assert.Equal(t, tc.url, tc.path.URL())
+			assert.Equal(t, tc.name, tc.path.Name())
+		})
+	}
+}
+
+func TestParseJobURL(t *testing.T) {
+	tcases := []struct {
+		url  string
+		path JobPath
+	}{
+		{"https://ci.example.com/job/app/", "app"},
+		{"https://ci.example.com/jenkins/job/team/job/app/job/main/", "team/app/main"},
+		{"job/team/job/app", "team/app"},
+		{"https://ci.example.com/job/team/job/app/12/console", "team/app"},
+		{"https://ci.example.com/", ""},
+		{"https://ci.example.com/job/app/job/feature%2Ffoo/", "app/feature%2Ffoo"},
+		{"https://ci.example.com/job/app/job/feature%252Ffoo/", "app/feature%2Ffoo"},
+		{"https://ci.example.com/job/my%20app%20%232/3/", "my app #2"},
+	}
+	for _, tc := range tcases {
+		t.Run(tc.url, func(t *testing.T) {
+			assert.Equal(t, tc.path, ParseJobURL(tc.url))
+			assert.True(t, tc.path.MatchURL(tc.url))
+			if tc.path != "" {
+				assert.Equal(t, tc.path, ParseJobURL(tc.path.URL()))
+			}
+		})
+	}
+	assert.Equal(t, JobPath("team/app"), JobPath("team").Join("app"))
+	assert.Equal(t, JobPath("app"), JobPath("").Join("app"))
+}
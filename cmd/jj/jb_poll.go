@@ -0,0 +1,88 @@
+package jj
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Polling configures how often a build or a queue item is polled,
+// the interval starts from Min while the console grows and doubles
+// up to Max while nothing happens
+type Polling struct {
+	Min time.Duration `yaml:"min,omitempty"`
+	Max time.Duration `yaml:"max,omitempty"`
+}
+
+const (
+	defaultPollMin = 200 * time.Millisecond
+	defaultPollMax = 5 * time.Second
+	// maxRetryAfter caps the pause requested by the Jenkins
+	maxRetryAfter = 5 * time.Minute
+)
+
+// Backoff returns a new backoff of the polling, nil polling gives the defaults
+func (p *Polling) Backoff() *Backoff {
+	b := &Backoff{Min: defaultPollMin, Max: defaultPollMax}
+	if p != nil && p.Min > 0 {
+		b.Min = p.Min
+	}
+	if p != nil && p.Max > 0 {
+		b.Max = p.Max
+	}
+	if b.Max < b.Min {
+		b.Max = b.Min
+	}
+	return b
+}
+
+// Backoff is the interval between polls
+type Backoff struct {
+	Min time.Duration
+	Max time.Duration
+	cur time.Duration
+}
+
+// Reset is called when there is some activity, the next poll is done after Min
+func (b *Backoff) Reset() {
+	b.cur = 0
+}
+
+// Next returns the interval before the next poll and doubles it
+func (b *Backoff) Next() time.Duration {
+	if b.cur < b.Min {
+		b.cur = b.Min
+	}
+	d := b.cur
+	b.cur *= 2
+	if b.cur > b.Max {
+		b.cur = b.Max
+	}
+	return d
+}
+
+// Sleep waits for the next poll
+func (b *Backoff) Sleep() {
+	time.Sleep(b.Next())
+}
+
+// parseRetryAfter reads the Retry-After header given in seconds or as a date
+func parseRetryAfter(h http.Header, now time.Time) time.Duration {
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+	var d time.Duration
+	if secs, err := strconv.Atoi(v); err == nil {
+		d = time.Duration(secs) * time.Second
+	} else if t, err := http.ParseTime(v); err == nil {
+		d = t.Sub(now)
+	}
+	if d < 0 {
+		return 0
+	}
+	if d > maxRetryAfter {
+		return maxRetryAfter
+	}
+	return d
+}
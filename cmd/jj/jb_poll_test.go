@@ -0,0 +1,70 @@
+package jj
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoff(t *testing.T) {
+	var p *Polling
+	b := p.Backoff()
+	assert.Equal(t, defaultPollMin, b.Next())
+
+	b = (&Polling{Min: time.Second, Max: 3 * time.Second}).Backoff()
+	assert.Equal(t, time.Second, b.Next())
+	assert.Equal(t, 2*time.Second, b.Next())
+	assert.Equal(t, 3*time.Second, b.Next())
+	assert.Equal(t, 3*time.Second, b.Next())
+	b.Reset()
+	assert.Equal(t, time.Second, b.Next())
+
+	b = (&Polling{Min: time.Second, Max: time.Millisecond}).Backoff()
+	assert.Equal(t, time.Second, b.Max)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2020, 8, 5, 15, 0, 0, 0, time.UTC)
+	tcases := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"3", 3 * time.Second},
+		{"Wed, 05 Aug 2020 15:00:10 GMT", 10 * time.Second},
+		{"Wed, 05 Aug 2020 14:00:00 GMT", 0},
+		{"86400", maxRetryAfter},
+		{"soon", 0},
+	}
+	for _, tc := range tcases {
+		h := http.Header{}
+		h.Set("Retry-After", tc.value)
+		assert.Equal(t, tc.want, parseRetryAfter(h, now), tc.value)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	var times []time.Time
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		times = append(times, time.Now())
+		if len(times) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(429)
+			return
+		}
+		w.Write([]byte("{}"))
+	}))
+	defer srv.Close()
+
+	c := NewClient(Env{Name: "retry-test", Url: srv.URL})
+	err := c.Check(context.Background())
+	assert.True(t, errors.Is(err, ErrTooMany))
+	assert.True(t, c.RetryAfter() > 0)
+	assert.NoError(t, c.Check(context.Background()))
+	assert.True(t, times[1].Sub(times[0]) >= 900*time.Millisecond)
+}
@@ -0,0 +1,197 @@
+package jj
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"gopkg.in/yaml.v2"
+)
+
+const presetsFile = "presets.yaml"
+
+var ErrNoPreset = errors.New("preset is not found")
+
+// Preset is a named set of parameter values of a job in a Jenkins environment
+type Preset struct {
+	Env    EName             `json:"env" yaml:"env"`
+	Job    string            `json:"job" yaml:"job"`
+	Name   string            `json:"name" yaml:"name"`
+	Params map[string]string `json:"params" yaml:"params"`
+}
+
+// PresetStore keeps presets in a yaml file grouped by environments and jobs
+type PresetStore struct {
+	// Path of the file, ~/.jj/presets.yaml when empty
+	Path string
+
+	mutex sync.Mutex
+}
+
+var DefaultPresetStore = &PresetStore{}
+
+// env -> job -> preset -> parameters
+type presetsData map[EName]map[string]map[string]map[string]string
+
+func (s *PresetStore) path() string {
+	if s.Path != "" {
+		return s.Path
+	}
+	loadConfig()
+	return filepath.Join(homeDir, presetsFile)
+}
+
+// List returns presets of the environment sorted by jobs and names,
+// presets of all jobs are returned when job is empty
+func (s *PresetStore) List(env EName, job string) ([]Preset, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	data, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	presets := []Preset{}
+	for j, byName := range data[env] {
+		if job != "" && j != job {
+			continue
+		}
+		for name, params := range byName {
+			presets = append(presets, Preset{Env: env, Job: j, Name: name, Params: params})
+		}
+	}
+	sort.Slice(presets, func(i, j int) bool {
+		if presets[i].Job != presets[j].Job {
+			return presets[i].Job < presets[j].Job
+		}
+		return presets[i].Name < presets[j].Name
+	})
+	return presets, nil
+}
+
+func (s *PresetStore) Get(env EName, job, name string) (Preset, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	data, err := s.read()
+	if err != nil {
+		return Preset{}, err
+	}
+	params, ok := data[env][job][name]
+	if !ok {
+		return Preset{}, fmt.Errorf("%w: '%s' of %s in %s", ErrNoPreset, name, job, env)
+	}
+	if params == nil {
+		params = map[string]string{}
+	}
+	return Preset{Env: env, Job: job, Name: name, Params: params}, nil
+}
+
+// Save stores the preset, a preset with the same name is replaced
+func (s *PresetStore) Save(p Preset) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	data, err := s.read()
+	if err != nil {
+		return err
+	}
+	if data[p.Env] == nil {
+		data[p.Env] = map[string]map[string]map[string]string{}
+	}
+	if data[p.Env][p.Job] == nil {
+		data[p.Env][p.Job] = map[string]map[string]string{}
+	}
+	data[p.Env][p.Job][p.Name] = p.Params
+	return s.write(data)
+}
+
+func (s *PresetStore) Delete(env EName, job, name string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	data, err := s.read()
+	if err != nil {
+		return err
+	}
+	if _, ok := data[env][job][name]; !ok {
+		return fmt.Errorf("%w: '%s' of %s in %s", ErrNoPreset, name, job, env)
+	}
+	delete(data[env][job], name)
+	if len(data[env][job]) == 0 {
+		delete(data[env], job)
+	}
+	if len(data[env]) == 0 {
+		delete(data, env)
+	}
+	return s.write(data)
+}
+
+// DeleteEnv removes all presets of the environment
+func (s *PresetStore) DeleteEnv(env EName) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	data, err := s.read()
+	if err != nil {
+		return err
+	}
+	if _, ok := data[env]; !ok {
+		return nil
+	}
+	delete(data, env)
+	return s.write(data)
+}
+
+func (s *PresetStore) read() (presetsData, error) {
+	data := presetsData{}
+	bin, err := ioutil.ReadFile(s.path())
+	if os.IsNotExist(err) {
+		return data, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(bin, &data); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", s.path(), err)
+	}
+	return data, nil
+}
+
+func (s *PresetStore) write(data presetsData) error {
+	bin, err := yaml.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path()), 0700); err != nil {
+		return err
+	}
+	// values may be as sensitive as the config
+	return ioutil.WriteFile(s.path(), bin, 0600)
+}
+
+// CheckPreset validates values of the preset against the current parameter
+// definitions of the job, the job could have changed since it was saved
+func CheckPreset(p Preset, params []ParameterDefinitions) error {
+	defs := map[string]ParameterDefinitions{}
+	for _, pd := range params {
+		defs[pd.Name] = pd
+	}
+	names := make([]string, 0, len(p.Params))
+	for name := range p.Params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		pd, ok := defs[name]
+		if !ok {
+			return fmt.Errorf("preset '%s': %s is not a parameter of %s anymore", p.Name, name, p.Job)
+		}
+		if pd.Kind() == ParamGit || (pd.Kind() == ParamFile && p.Params[name] == "") {
+			continue
+		}
+		if _, err := pd.Normalize(p.Params[name]); err != nil {
+			return fmt.Errorf("preset '%s': %w", p.Name, err)
+		}
+	}
+	return nil
+}
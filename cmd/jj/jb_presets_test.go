@@ -0,0 +1,62 @@
+package jj_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPresetStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jj")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	s := &jj.PresetStore{Path: filepath.Join(dir, "presets.yaml")}
+
+	hotfix := jj.Preset{Env: "prod", Job: "app", Name: "hotfix", Params: map[string]string{"BRANCH": "hotfix"}}
+	assert.NoError(t, s.Save(hotfix))
+	assert.NoError(t, s.Save(jj.Preset{Env: "prod", Job: "app", Name: "daily", Params: map[string]string{"BRANCH": "master"}}))
+	assert.NoError(t, s.Save(jj.Preset{Env: "uat", Job: "app", Name: "hotfix", Params: map[string]string{"BRANCH": "develop"}}))
+
+	p, err := s.Get("prod", "app", "hotfix")
+	assert.NoError(t, err)
+	assert.Equal(t, hotfix, p)
+	_, err = s.Get("prod", "web", "hotfix")
+	assert.True(t, errors.Is(err, jj.ErrNoPreset))
+
+	presets, err := s.List("prod", "")
+	assert.NoError(t, err)
+	assert.Len(t, presets, 2)
+	assert.Equal(t, "daily", presets[0].Name)
+
+	assert.NoError(t, s.Delete("prod", "app", "daily"))
+	assert.True(t, errors.Is(s.Delete("prod", "app", "daily"), jj.ErrNoPreset))
+	assert.NoError(t, s.DeleteEnv("uat"))
+	presets, err = s.List("uat", "app")
+	assert.NoError(t, err)
+	assert.Len(t, presets, 0)
+
+	info, err := os.Stat(s.Path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestCheckPreset(t *testing.T) {
+	params := []jj.ParameterDefinitions{
+		{Name: "BRANCH", Type: "StringParameterDefinition"},
+		{Name: "TARGET", Type: "ChoiceParameterDefinition", Choices: []string{"uat", "prod"}},
+	}
+	p := jj.Preset{Job: "app", Name: "hotfix", Params: map[string]string{"BRANCH": "hotfix", "TARGET": "prod"}}
+	assert.NoError(t, jj.CheckPreset(p, params))
+
+	p.Params["TARGET"] = "dev"
+	assert.EqualError(t, jj.CheckPreset(p, params), "preset 'hotfix': TARGET: 'dev' is not one of uat, prod")
+
+	p.Params["TARGET"] = "prod"
+	p.Params["DEBUG"] = "true"
+	assert.EqualError(t, jj.CheckPreset(p, params), "preset 'hotfix': DEBUG is not a parameter of app anymore")
+}
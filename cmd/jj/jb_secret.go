@@ -0,0 +1,323 @@
+package jj
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const secretsFile = "secrets"
+
+// SecretProvider resolves the argument of a secret reference like
+// "env:JENKINS_TOKEN" into the secret itself
+type SecretProvider interface {
+	Resolve(ctx context.Context, arg string) (string, error)
+}
+
+var ErrNoSecret = errors.New("secret is not available")
+
+var secretProviders = map[string]SecretProvider{
+	"env":     EnvSecrets{},
+	"command": CommandSecrets{},
+	"file":    DefaultFileStore,
+}
+var secretProvidersMutex sync.Mutex
+
+// RegisterSecretProvider makes the provider available for references
+// with the given scheme
+func RegisterSecretProvider(scheme string, p SecretProvider) {
+	secretProvidersMutex.Lock()
+	defer secretProvidersMutex.Unlock()
+	secretProviders[scheme] = p
+}
+
+// SecretRef builds a reference which is stored in the config instead of the secret
+func SecretRef(scheme, arg string) string {
+	return scheme + ":" + arg
+}
+
+// ResolveSecret returns the secret of the environment, the plain text secret
+// of older configs is used when the environment has no reference
+func ResolveSecret(ctx context.Context, env Env) (string, error) {
+	if env.SecretRef == "" {
+		return env.Secret, nil
+	}
+	parts := strings.SplitN(env.SecretRef, ":", 2)
+	if len(parts) != 2 {
+		return "", fmt.Errorf("invalid secret reference '%s'", env.SecretRef)
+	}
+	secretProvidersMutex.Lock()
+	p, ok := secretProviders[parts[0]]
+	secretProvidersMutex.Unlock()
+	if !ok {
+		return "", fmt.Errorf("unknown secret provider '%s'", parts[0])
+	}
+	secret, err := p.Resolve(ctx, parts[1])
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve secret of %s: %w", env.Name, err)
+	}
+	return secret, nil
+}
+
+// EnvSecrets reads secrets from environment variables
+type EnvSecrets struct{}
+
+func (EnvSecrets) Resolve(ctx context.Context, name string) (string, error) {
+	secret, ok := os.LookupEnv(name)
+	if !ok || secret == "" {
+		return "", fmt.Errorf("%w: $%s is not set", ErrNoSecret, name)
+	}
+	return secret, nil
+}
+
+// CommandSecrets runs the shell command and takes the first line of its
+// output as the secret, the same way as git credential helpers or `pass show`
+type CommandSecrets struct{}
+
+func (CommandSecrets) Resolve(ctx context.Context, command string) (string, error) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("secret command failed: %w", err)
+	}
+	secret := strings.TrimSpace(strings.SplitN(string(out), "\n", 2)[0])
+	if secret == "" {
+		return "", fmt.Errorf("%w: secret command printed nothing", ErrNoSecret)
+	}
+	return secret, nil
+}
+
+// FileStore keeps secrets in a file encrypted by AES-GCM with a key
+// derived from the passphrase
+type FileStore struct {
+	// Path of the file, ~/.jj/secrets when empty
+	Path string
+	// Passphrase is asked when the file is opened for the first time,
+	// create is true when the file does not exist yet
+	Passphrase func(create bool) (string, error)
+
+	mutex sync.Mutex
+	key   []byte
+}
+
+// DefaultFileStore reads the passphrase from $JJ_PASSPHRASE,
+// the cmd package replaces it with a prompt
+var DefaultFileStore = &FileStore{Passphrase: PassphraseFromEnv}
+
+func PassphraseFromEnv(create bool) (string, error) {
+	if p := os.Getenv("JJ_PASSPHRASE"); p != "" {
+		return p, nil
+	}
+	return "", fmt.Errorf("%w: $JJ_PASSPHRASE is not set", ErrNoSecret)
+}
+
+type secretsData struct {
+	Salt    string            `json:"salt"`
+	Secrets map[string]string `json:"secrets"`
+}
+
+const pbkdf2Iterations = 100000
+
+func (s *FileStore) path() string {
+	if s.Path != "" {
+		return s.Path
+	}
+	loadConfig()
+	return filepath.Join(homeDir, secretsFile)
+}
+
+func (s *FileStore) Resolve(ctx context.Context, name string) (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	data, err := s.read()
+	if err != nil {
+		return "", err
+	}
+	sealed, ok := data.Secrets[name]
+	if !ok {
+		return "", fmt.Errorf("%w: no secret '%s' in %s", ErrNoSecret, name, s.path())
+	}
+	if err := s.unlock(data, false); err != nil {
+		return "", err
+	}
+	return s.open(sealed)
+}
+
+// Store encrypts the secret and saves it under the name
+func (s *FileStore) Store(name, secret string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	data, err := s.read()
+	if err != nil {
+		return err
+	}
+	create := data.Salt == ""
+	if create {
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return err
+		}
+		data.Salt = base64.StdEncoding.EncodeToString(salt)
+	}
+	if err := s.unlock(data, create); err != nil {
+		return err
+	}
+	// the passphrase is checked against any existing secret
+	for _, sealed := range data.Secrets {
+		if _, err := s.open(sealed); err != nil {
+			return err
+		}
+		break
+	}
+	sealed, err := s.seal(secret)
+	if err != nil {
+		return err
+	}
+	data.Secrets[name] = sealed
+	return s.write(data)
+}
+
+// Delete removes the secret, a missing secret is not an error
+func (s *FileStore) Delete(name string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	data, err := s.read()
+	if err != nil {
+		return err
+	}
+	if _, ok := data.Secrets[name]; !ok {
+		return nil
+	}
+	delete(data.Secrets, name)
+	return s.write(data)
+}
+
+func (s *FileStore) read() (*secretsData, error) {
+	data := &secretsData{Secrets: map[string]string{}}
+	bin, err := ioutil.ReadFile(s.path())
+	if os.IsNotExist(err) {
+		return data, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(bin, data); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", s.path(), err)
+	}
+	if data.Secrets == nil {
+		data.Secrets = map[string]string{}
+	}
+	return data, nil
+}
+
+func (s *FileStore) write(data *secretsData) error {
+	bin, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path()), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(s.path(), bin, 0600)
+}
+
+func (s *FileStore) unlock(data *secretsData, create bool) error {
+	if s.key != nil {
+		return nil
+	}
+	if s.Passphrase == nil {
+		return fmt.Errorf("%w: no passphrase for %s", ErrNoSecret, s.path())
+	}
+	passphrase, err := s.Passphrase(create)
+	if err != nil {
+		return err
+	}
+	salt, err := base64.StdEncoding.DecodeString(data.Salt)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", s.path(), err)
+	}
+	s.key = pbkdf2([]byte(passphrase), salt, pbkdf2Iterations, 32)
+	return nil
+}
+
+func (s *FileStore) seal(secret string) (string, error) {
+	gcm, err := s.gcm()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(secret), nil)), nil
+}
+
+func (s *FileStore) open(sealed string) (string, error) {
+	gcm, err := s.gcm()
+	if err != nil {
+		return "", err
+	}
+	bin, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil || len(bin) < gcm.NonceSize() {
+		return "", fmt.Errorf("corrupted secret in %s", s.path())
+	}
+	plain, err := gcm.Open(nil, bin[:gcm.NonceSize()], bin[gcm.NonceSize():], nil)
+	if err != nil {
+		// a wrong passphrase must be asked again
+		s.key = nil
+		return "", errors.New("wrong passphrase")
+	}
+	return string(plain), nil
+}
+
+func (s *FileStore) gcm() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(s.key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// pbkdf2 implements PBKDF2 with HMAC-SHA256 from RFC 8018
+func pbkdf2(password, salt []byte, iter, keyLen int) []byte {
+	prf := hmac.New(sha256.New, password)
+	hashLen := prf.Size()
+	numBlocks := (keyLen + hashLen - 1) / hashLen
+	var buf [4]byte
+	dk := make([]byte, 0, numBlocks*hashLen)
+	u := make([]byte, hashLen)
+	for block := 1; block <= numBlocks; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		binary.BigEndian.PutUint32(buf[:], uint32(block))
+		prf.Write(buf[:])
+		dk = prf.Sum(dk)
+		t := dk[len(dk)-hashLen:]
+		copy(u, t)
+		for n := 2; n <= iter; n++ {
+			prf.Reset()
+			prf.Write(u)
+			u = u[:0]
+			u = prf.Sum(u)
+			for i := range u {
+				t[i] ^= u[i]
+			}
+		}
+	}
+	return dk[:keyLen]
+}
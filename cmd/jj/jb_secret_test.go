@@ -0,0 +1,111 @@
+package jj
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveSecret(t *testing.T) {
+	ctx := context.Background()
+	secret, err := ResolveSecret(ctx, Env{Secret: "plain"})
+	assert.NoError(t, err)
+	assert.Equal(t, "plain", secret)
+
+	os.Setenv("JJ_TEST_TOKEN", "from-env")
+	defer os.Unsetenv("JJ_TEST_TOKEN")
+	secret, err = ResolveSecret(ctx, Env{SecretRef: SecretRef("env", "JJ_TEST_TOKEN")})
+	assert.NoError(t, err)
+	assert.Equal(t, "from-env", secret)
+
+	_, err = ResolveSecret(ctx, Env{SecretRef: "env:JJ_TEST_MISSING"})
+	assert.True(t, errors.Is(err, ErrNoSecret))
+
+	secret, err = ResolveSecret(ctx, Env{SecretRef: "command:printf 'from-command\\nrest'"})
+	assert.NoError(t, err)
+	assert.Equal(t, "from-command", secret)
+
+	_, err = ResolveSecret(ctx, Env{SecretRef: "vault:jenkins"})
+	assert.Error(t, err)
+}
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jj")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "secrets")
+	passphrase := "right"
+	newStore := func() *FileStore {
+		return &FileStore{Path: path, Passphrase: func(create bool) (string, error) { return passphrase, nil }}
+	}
+
+	s := newStore()
+	assert.NoError(t, s.Store("dev", "token-1"))
+	assert.NoError(t, s.Store("prod", "token-2"))
+	bin, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(bin), "token-1")
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	secret, err := newStore().Resolve(context.Background(), "prod")
+	assert.NoError(t, err)
+	assert.Equal(t, "token-2", secret)
+
+	passphrase = "wrong"
+	_, err = newStore().Resolve(context.Background(), "dev")
+	assert.Error(t, err)
+	assert.Error(t, newStore().Store("qa", "token-3"))
+
+	passphrase = "right"
+	assert.NoError(t, s.Delete("dev"))
+	_, err = newStore().Resolve(context.Background(), "dev")
+	assert.True(t, errors.Is(err, ErrNoSecret))
+}
+
+func TestClientResolvesSecretLazily(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if login, secret, _ := r.BasicAuth(); login != "admin" || secret != "lazy" {
+			w.WriteHeader(401)
+			return
+		}
+		w.Write([]byte("{}"))
+	}))
+	defer srv.Close()
+
+	c := NewClient(Env{Name: "secret-test", Url: srv.URL, Type: "a", Login: "admin", SecretRef: "env:JJ_TEST_LAZY"})
+	assert.True(t, errors.Is(c.Check(context.Background()), ErrNoSecret))
+	os.Setenv("JJ_TEST_LAZY", "lazy")
+	defer os.Unsetenv("JJ_TEST_LAZY")
+	assert.NoError(t, c.Check(context.Background()))
+}
+
+// known answers of PBKDF2-HMAC-SHA256, a change of the derivation would make
+// the secrets saved by earlier versions unreadable
+func TestPBKDF2(t *testing.T) {
+	tcases := []struct {
+		password string
+		salt     string
+		iter     int
+		keyLen   int
+		key      string
+	}{
+		{"password", "salt", 1, 32, "120fb6cffcf8b32c43e7225256c4f837a86548c92ccc35480805987cb70be17b"},
+		{"password", "salt", 4096, 40, "c5e478d59288c841aa530db6845c4c8d962893a001ce4e11a4963873aa98134af7ad98c1b458ce3f"},
+		{"passwordPASSWORDpassword", "saltSALTsaltSALTsaltSALTsaltSALTsalt", 4096, 40,
+			"348c89dbcbd32b2f32d814b8116e84cf2b17347ebc1800181c4e2a1fb8dd53e1c635518c7dac47e9"},
+	}
+	for _, tc := range tcases {
+		key := pbkdf2([]byte(tc.password), []byte(tc.salt), tc.iter, tc.keyLen)
+		assert.Equal(t, tc.key, hex.EncodeToString(key))
+	}
+}
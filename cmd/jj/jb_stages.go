@@ -0,0 +1,130 @@
+package jj
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+// Statuses of pipeline runs, stages and flow nodes reported by the
+// Pipeline Stage View plugin
+const (
+	StageSuccess     = "SUCCESS"
+	StageFailed      = "FAILED"
+	StageUnstable    = "UNSTABLE"
+	StageAborted     = "ABORTED"
+	StageInProgress  = "IN_PROGRESS"
+	StagePaused      = "PAUSED_PENDING_INPUT"
+	StageNotExecuted = "NOT_EXECUTED"
+)
+
+// PipelineRun is a build of a Pipeline job described by wfapi
+type PipelineRun struct {
+	ID              string  `json:"id"`
+	Name            string  `json:"name"`
+	Status          string  `json:"status"`
+	StartTimeMillis int64   `json:"startTimeMillis"`
+	DurationMillis  int64   `json:"durationMillis"`
+	Stages          []Stage `json:"stages"`
+}
+
+// Stage of a pipeline run, stages of parallel blocks are listed one after
+// another and overlap in time
+type Stage struct {
+	ID                  string `json:"id"`
+	Name                string `json:"name"`
+	ExecNode            string `json:"execNode"`
+	Status              string `json:"status"`
+	StartTimeMillis     int64  `json:"startTimeMillis"`
+	DurationMillis      int64  `json:"durationMillis"`
+	PauseDurationMillis int64  `json:"pauseDurationMillis"`
+}
+
+// Running tells whether the stage hasn't finished yet
+func (s Stage) Running() bool {
+	return s.Status == StageInProgress || s.Status == StagePaused
+}
+
+// StageDetail is a stage with the flow nodes (steps) it has run
+type StageDetail struct {
+	Stage
+	StageFlowNodes []FlowNode `json:"stageFlowNodes"`
+}
+
+type FlowNode struct {
+	ID                   string   `json:"id"`
+	Name                 string   `json:"name"`
+	Status               string   `json:"status"`
+	ParameterDescription string   `json:"parameterDescription"`
+	StartTimeMillis      int64    `json:"startTimeMillis"`
+	DurationMillis       int64    `json:"durationMillis"`
+	ParentNodes          []string `json:"parentNodes"`
+}
+
+// NodeLog is the log of a flow node, the text is html
+type NodeLog struct {
+	NodeID     string `json:"nodeId"`
+	NodeStatus string `json:"nodeStatus"`
+	Length     int64  `json:"length"`
+	HasMore    bool   `json:"hasMore"`
+	Text       string `json:"text"`
+	ConsoleURL string `json:"consoleUrl"`
+}
+
+// DescribeRun returns stages of the build, it fails with ErrNotFound
+// when the job is not a Pipeline or the Stage View plugin is not installed
+func (c *Client) DescribeRun(ctx context.Context, job string, id int) (*PipelineRun, error) {
+	var run PipelineRun
+	if err := c.reqJSON(ctx, "GET", JobPath(job).URL()+"/"+strconv.Itoa(id)+"/wfapi/describe", &run); err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+func (c *Client) DescribeStage(ctx context.Context, job string, id int, stageID string) (*StageDetail, error) {
+	var stage StageDetail
+	if err := c.reqJSON(ctx, "GET", nodePath(job, id, stageID)+"/wfapi/describe", &stage); err != nil {
+		return nil, err
+	}
+	return &stage, nil
+}
+
+func (c *Client) GetNodeLog(ctx context.Context, job string, id int, nodeID string) (*NodeLog, error) {
+	var log NodeLog
+	if err := c.reqJSON(ctx, "GET", nodePath(job, id, nodeID)+"/wfapi/log", &log); err != nil {
+		return nil, err
+	}
+	return &log, nil
+}
+
+// GetStageLog returns logs of all flow nodes of the stage joined in the
+// order of the nodes, the logs are fetched in parallel
+func (c *Client) GetStageLog(ctx context.Context, job string, id int, stageID string) (string, error) {
+	stage, err := c.DescribeStage(ctx, job, id, stageID)
+	if err != nil {
+		return "", err
+	}
+	logs := make([]string, len(stage.StageFlowNodes))
+	err = c.parallel(len(logs), func(i int) error {
+		log, err := c.GetNodeLog(ctx, job, id, stage.StageFlowNodes[i].ID)
+		if err == nil {
+			logs[i] = log.Text
+		}
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	var text strings.Builder
+	for _, log := range logs {
+		text.WriteString(log)
+		if log != "" && !strings.HasSuffix(log, "\n") {
+			text.WriteString("\n")
+		}
+	}
+	return text.String(), nil
+}
+
+func nodePath(job string, id int, nodeID string) string {
+	return JobPath(job).URL() + "/" + strconv.Itoa(id) + "/execution/node/" + nodeID
+}
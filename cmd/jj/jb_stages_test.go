@@ -0,0 +1,51 @@
+package jj_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj"
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj/jjtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDescribeRun(t *testing.T) {
+	srv := jjtest.New()
+	defer srv.Close()
+	job := srv.AddJob("pipeline")
+	job.Script = jjtest.Script{Log: []string{"checkout\n", "build\n"}}
+	job.Stages = []jjtest.Stage{
+		{Name: "Checkout", Log: "git clone\n"},
+		{Name: "Build", Log: "make\nmake install"},
+	}
+	srv.AddJob("freestyle")
+
+	c := newClient(t, srv)
+	for _, name := range []string{"pipeline", "freestyle"} {
+		queueId, err := c.Build(ctx, name, "")
+		assert.NoError(t, err)
+		_, err = c.GetQueueInfo(ctx, queueId)
+		assert.NoError(t, err)
+	}
+
+	run, err := c.DescribeRun(ctx, "pipeline", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, jj.StageInProgress, run.Status)
+	assert.Len(t, run.Stages, 2)
+	assert.True(t, run.Stages[0].Running())
+	assert.Equal(t, jj.StageNotExecuted, run.Stages[1].Status)
+
+	_, _, err = c.Console(ctx, "pipeline", 1, "0")
+	assert.NoError(t, err)
+	run, err = c.DescribeRun(ctx, "pipeline", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, jj.StageSuccess, run.Stages[0].Status)
+	assert.Equal(t, jj.StageInProgress, run.Stages[1].Status)
+
+	text, err := c.GetStageLog(ctx, "pipeline", 1, run.Stages[1].ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "make\nmake install\n", text)
+
+	_, err = c.DescribeRun(ctx, "freestyle", 1)
+	assert.True(t, errors.Is(err, jj.ErrNotFound))
+}
@@ -1,41 +1,222 @@
-package jj
+package jj_test
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj"
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj/jjtest"
 	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
 	"testing"
-	"time"
 )
 
-func getEnv(name string) Env {
-	for _, e := range GetEnvs() {
-		if e.Name == EName(name) {
-			return e
-		}
-	}
-	panic("no any envs")
+var ctx = context.Background()
+
+func newClient(t *testing.T, srv *jjtest.Server) *jj.Client {
+	dir, err := ioutil.TempDir("", "jj")
+	assert.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return jj.NewClient(srv.Env(), jj.WithCacheDir(dir))
 }
 
 func TestInit(t *testing.T) {
-	e := getEnv("uat")
-	time.Sleep(time.Second * 3)
-	GetJobInfo(e, "core-change-zone")
-	fmt.Println("jd ", e)
+	srv := jjtest.New()
+	defer srv.Close()
+	srv.AddJob("core-change-zone")
+	srv.AddJob("team/app/main")
+	srv.AddJob("team/app/develop")
+
+	c := newClient(t, srv)
+	assert.Nil(t, c.Bundle())
+	assert.NoError(t, c.LoadBundle(ctx))
+	names := []string{}
+	for _, j := range c.Bundle().AllJobs() {
+		names = append(names, j.Name)
+	}
+	assert.ElementsMatch(t, []string{"core-change-zone", "team/app/main", "team/app/develop"}, names)
+
+	ji, err := c.GetJobInfo(ctx, "team/app/main")
+	assert.NoError(t, err)
+	assert.Equal(t, "team/app/main", ji.FullName)
+	assert.Equal(t, ji, c.CachedJobInfo("team/app/main"))
+
+	_, err = c.GetJobInfo(ctx, "missing")
+	assert.True(t, errors.Is(err, jj.ErrNotFound))
+}
+
+func TestRefreshBundle(t *testing.T) {
+	srv := jjtest.New()
+	defer srv.Close()
+	names := []string{}
+	for i := 0; i < 6; i++ {
+		name := "app-" + strconv.Itoa(i)
+		srv.AddJob(name)
+		srv.AddView("view-"+strconv.Itoa(i), name)
+		names = append(names, name)
+	}
+	srv.AddJob("team/api/main")
+	srv.AddView("team", "team")
+	names = append(names, "team/api/main")
+
+	dir, err := ioutil.TempDir("", "jj")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	c := jj.NewClient(srv.Env(), jj.WithCacheDir(dir), jj.WithConcurrency(2))
+	assert.NoError(t, c.RefreshBundle(ctx))
+	assert.Len(t, c.Bundle().Views, 8)
+	found := []string{}
+	for _, j := range c.Bundle().AllJobs() {
+		found = append(found, j.Name)
+	}
+	assert.ElementsMatch(t, names, found)
+	assert.Equal(t, 1, srv.Requests["/job/team/api/json"])
+}
+
+func TestBuild(t *testing.T) {
+	srv := jjtest.New()
+	defer srv.Close()
+	srv.AddJob("app").Script = jjtest.Script{QueuePolls: 2, Log: []string{"line 1\n", "line 2\n"}, Result: "UNSTABLE"}
+
+	c := newClient(t, srv)
+	queueId, err := c.Build(ctx, "app", "BRANCH=master")
+	assert.NoError(t, err)
+
+	var qi *jj.QueueInfo
+	for i := 0; i < 3; i++ {
+		qi, err = c.GetQueueInfo(ctx, queueId)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 1, qi.Executable.Number)
+
+	bi, err := c.GetBuildInfo(ctx, "app", 1)
+	assert.NoError(t, err)
+	assert.True(t, bi.Building)
+	assert.Equal(t, "master", bi.Actions[0].Parameters[0].Value)
+
+	out, cursor, err := c.Console(ctx, "app", 1, "0")
+	assert.NoError(t, err)
+	assert.Equal(t, "line 1\n", out)
+	out, cursor, err = c.Console(ctx, "app", 1, cursor)
+	assert.NoError(t, err)
+	assert.Equal(t, "line 2\n", out)
+	assert.Equal(t, "14", cursor)
+
+	bi, err = c.GetBuildInfo(ctx, "app", 1)
+	assert.NoError(t, err)
+	assert.False(t, bi.Building)
+	assert.Equal(t, "UNSTABLE", bi.Result)
+}
+
+func TestGetBuilds(t *testing.T) {
+	srv := jjtest.New()
+	defer srv.Close()
+	srv.AddJob("app")
+
+	c := newClient(t, srv)
+	for _, branch := range []string{"master", "develop"} {
+		queueId, err := c.Build(ctx, "app", "BRANCH="+branch)
+		assert.NoError(t, err)
+		_, err = c.GetQueueInfo(ctx, queueId)
+		assert.NoError(t, err)
+	}
+	builds, err := c.GetBuilds(ctx, "app")
+	assert.NoError(t, err)
+	assert.Len(t, builds, 2)
+	assert.Equal(t, 2, builds[0].Number)
+	assert.Equal(t, "SUCCESS", builds[0].Result)
+	assert.Equal(t, []jj.Parameter{{Name: "BRANCH", Value: "develop"}}, builds[0].Parameters)
+	assert.Equal(t, []jj.Parameter{{Name: "BRANCH", Value: "master"}}, builds[1].Parameters)
 }
 
 func TestGetLastSuccessfulBuildDuration(t *testing.T) {
-	rsp, err := GetLastSuccessfulBuildInfo(getEnv("pi"), "config-deploy-manual")
+	srv := jjtest.New()
+	defer srv.Close()
+	srv.AddJob("config-deploy-manual").Script = jjtest.Script{Duration: 4200}
+
+	c := newClient(t, srv)
+	_, err := c.GetLastSuccessfulBuildInfo(ctx, "config-deploy-manual")
+	assert.True(t, errors.Is(err, jj.ErrNotFound))
+
+	queueId, err := c.Build(ctx, "config-deploy-manual", "")
+	assert.NoError(t, err)
+	_, err = c.GetQueueInfo(ctx, queueId)
+	assert.NoError(t, err)
+	rsp, err := c.GetLastSuccessfulBuildInfo(ctx, "config-deploy-manual")
 	assert.NoError(t, err)
-	fmt.Println("jd ", rsp)
+	assert.Equal(t, 4200, rsp.Duration)
 }
 
 func TestCancelJob(t *testing.T) {
-	status, err := CancelJob(getEnv("uat"), "web-rpm-build-manual", 40)
+	srv := jjtest.New()
+	defer srv.Close()
+	srv.AddJob("web-rpm-build-manual").Script = jjtest.Script{Log: []string{"a", "b", "c"}}
+
+	c := newClient(t, srv)
+	queueId, err := c.Build(ctx, "web-rpm-build-manual", "")
+	assert.NoError(t, err)
+	_, err = c.GetQueueInfo(ctx, queueId)
+	assert.NoError(t, err)
+
+	status, err := c.CancelJob(ctx, "web-rpm-build-manual", 1)
 	assert.NoError(t, err)
-	fmt.Println(status)
+	assert.Equal(t, "ABORTED", status)
 }
 
 func TestCancelQueue(t *testing.T) {
-	CancelQueue(getEnv("uat"), 657)
+	srv := jjtest.New()
+	defer srv.Close()
+	srv.AddJob("app").Script = jjtest.Script{QueuePolls: 10}
+
+	c := newClient(t, srv)
+	queueId, err := c.Build(ctx, "app", "")
+	assert.NoError(t, err)
+	queues, err := c.GetQueues(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, queues.Items, 1)
 
+	assert.NoError(t, c.CancelQueue(ctx, queueId))
+	qi, err := c.GetQueueInfo(ctx, queueId)
+	assert.NoError(t, err)
+	assert.True(t, qi.Cancelled)
+	queues, err = c.GetQueues(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, queues.Items, 0)
+}
+
+func TestCrumb(t *testing.T) {
+	crumbs := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/crumbIssuer/api/json":
+			crumbs++
+			http.SetCookie(w, &http.Cookie{Name: "JSESSIONID", Value: strconv.Itoa(crumbs), Path: "/"})
+			fmt.Fprintf(w, `{"crumb":"c%d","crumbRequestField":"Jenkins-Crumb"}`, crumbs)
+		default:
+			cookie, err := r.Cookie("JSESSIONID")
+			// the first crumb is treated as expired
+			if err != nil || r.Header.Get("Jenkins-Crumb") != "c"+cookie.Value || cookie.Value == "1" {
+				w.WriteHeader(403)
+				fmt.Fprint(w, "No valid crumb was included in the request")
+				return
+			}
+			w.WriteHeader(201)
+		}
+	}))
+	defer srv.Close()
+
+	c := jj.NewClient(jj.Env{Name: "crumb-test", Url: srv.URL})
+	code, _, _, err := c.Req(ctx, "POST", "job/app/build", []byte{})
+	assert.NoError(t, err)
+	assert.Equal(t, 201, code)
+	assert.Equal(t, 2, crumbs)
+
+	code, _, _, err = c.Req(ctx, "POST", "job/app/build", []byte{})
+	assert.NoError(t, err)
+	assert.Equal(t, 201, code)
+	assert.Equal(t, 2, crumbs)
 }
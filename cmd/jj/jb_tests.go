@@ -0,0 +1,77 @@
+package jj
+
+import (
+	"context"
+	"strconv"
+)
+
+// TestReport is the JUnit report of a build published by the JUnit plugin
+type TestReport struct {
+	// Duration in seconds
+	Duration  float64     `json:"duration"`
+	FailCount int         `json:"failCount"`
+	PassCount int         `json:"passCount"`
+	SkipCount int         `json:"skipCount"`
+	Suites    []TestSuite `json:"suites"`
+}
+
+type TestSuite struct {
+	Name     string     `json:"name"`
+	Duration float64    `json:"duration"`
+	Cases    []TestCase `json:"cases"`
+}
+
+type TestCase struct {
+	ClassName string `json:"className"`
+	Name      string `json:"name"`
+	// Status is PASSED, FIXED, SKIPPED, FAILED or REGRESSION
+	Status          string  `json:"status"`
+	Duration        float64 `json:"duration"`
+	ErrorDetails    string  `json:"errorDetails"`
+	ErrorStackTrace string  `json:"errorStackTrace"`
+	// Age is the number of builds the case has been failing in
+	Age         int `json:"age"`
+	FailedSince int `json:"failedSince"`
+}
+
+func (tc TestCase) Failed() bool {
+	return tc.Status == "FAILED" || tc.Status == "REGRESSION"
+}
+
+func (tc TestCase) FullName() string {
+	if tc.ClassName == "" {
+		return tc.Name
+	}
+	return tc.ClassName + "." + tc.Name
+}
+
+func (r *TestReport) Total() int {
+	return r.FailCount + r.PassCount + r.SkipCount
+}
+
+// FailedCases returns failed cases of all suites in the order of the report
+func (r *TestReport) FailedCases() []TestCase {
+	failed := []TestCase{}
+	for _, s := range r.Suites {
+		for _, tc := range s.Cases {
+			if tc.Failed() {
+				failed = append(failed, tc)
+			}
+		}
+	}
+	return failed
+}
+
+const testReportTree = "duration,failCount,passCount,skipCount," +
+	"suites[name,duration,cases[className,name,status,duration,errorDetails,errorStackTrace,age,failedSince]]"
+
+// GetTestReport returns the test report of the build, it fails with
+// ErrNotFound when the build hasn't published one
+func (c *Client) GetTestReport(ctx context.Context, job string, id int) (*TestReport, error) {
+	var report TestReport
+	path := JobPath(job).URL() + "/" + strconv.Itoa(id) + "/testReport/api/json?tree=" + testReportTree
+	if err := c.reqJSON(ctx, "POST", path, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
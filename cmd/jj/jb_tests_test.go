@@ -0,0 +1,48 @@
+package jj_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj"
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj/jjtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetTestReport(t *testing.T) {
+	srv := jjtest.New()
+	defer srv.Close()
+	job := srv.AddJob("app")
+	job.Script = jjtest.Script{Result: "UNSTABLE"}
+	srv.AddJob("web")
+
+	c := newClient(t, srv)
+	for _, name := range []string{"app", "web"} {
+		queueId, err := c.Build(ctx, name, "")
+		assert.NoError(t, err)
+		_, err = c.GetQueueInfo(ctx, queueId)
+		assert.NoError(t, err)
+	}
+	job.TestReport = &jj.TestReport{FailCount: 2, PassCount: 3, SkipCount: 1, Suites: []jj.TestSuite{
+		{Name: "unit", Cases: []jj.TestCase{
+			{ClassName: "app.UserTest", Name: "login", Status: "PASSED"},
+			{ClassName: "app.UserTest", Name: "logout", Status: "REGRESSION", ErrorDetails: "expected 1", Age: 1},
+		}},
+		{Name: "it", Cases: []jj.TestCase{
+			{ClassName: "app.ApiTest", Name: "get", Status: "FAILED", Age: 3, FailedSince: 7},
+			{ClassName: "app.ApiTest", Name: "put", Status: "FIXED"},
+		}},
+	}}
+
+	report, err := c.GetTestReport(ctx, "app", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 6, report.Total())
+	failed := report.FailedCases()
+	assert.Len(t, failed, 2)
+	assert.Equal(t, "app.UserTest.logout", failed[0].FullName())
+	assert.Equal(t, "expected 1", failed[0].ErrorDetails)
+	assert.Equal(t, 7, failed[1].FailedSince)
+
+	_, err = c.GetTestReport(ctx, "web", 1)
+	assert.True(t, errors.Is(err, jj.ErrNotFound))
+}
@@ -0,0 +1,53 @@
+package jj
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+)
+
+// TLS describes how the certificate of the Jenkins is verified
+type TLS struct {
+	Verify bool `yaml:"verify"`
+	// CAFile is a PEM bundle trusted in addition to the system roots
+	CAFile string `yaml:"ca_file,omitempty"`
+	// CertFile and KeyFile are the client certificate for mutual TLS
+	CertFile   string `yaml:"cert_file,omitempty"`
+	KeyFile    string `yaml:"key_file,omitempty"`
+	ServerName string `yaml:"server_name,omitempty"`
+}
+
+// Config builds the tls config, nil TLS means an environment from an older
+// config which never verified certificates
+func (t *TLS) Config() (*tls.Config, error) {
+	if t == nil {
+		return &tls.Config{InsecureSkipVerify: true}, nil
+	}
+	cfg := &tls.Config{
+		InsecureSkipVerify: !t.Verify,
+		ServerName:         t.ServerName,
+	}
+	if t.CAFile != "" {
+		pem, err := ioutil.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", t.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+	if t.CertFile != "" || t.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
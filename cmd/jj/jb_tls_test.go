@@ -0,0 +1,52 @@
+package jj
+
+import (
+	"context"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTLSConfig(t *testing.T) {
+	var legacy *TLS
+	cfg, err := legacy.Config()
+	assert.NoError(t, err)
+	assert.True(t, cfg.InsecureSkipVerify)
+
+	cfg, err = (&TLS{Verify: true, ServerName: "jenkins.local"}).Config()
+	assert.NoError(t, err)
+	assert.False(t, cfg.InsecureSkipVerify)
+	assert.Equal(t, "jenkins.local", cfg.ServerName)
+
+	_, err = (&TLS{Verify: true, CAFile: "/nonexistent/ca.pem"}).Config()
+	assert.Error(t, err)
+}
+
+func TestTLSVerify(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("{}"))
+	}))
+	defer srv.Close()
+	dir, err := ioutil.TempDir("", "jj")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	caFile := filepath.Join(dir, "ca.pem")
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	assert.NoError(t, ioutil.WriteFile(caFile, ca, 0600))
+
+	ctx := context.Background()
+	env := Env{Name: "tls-test", Url: srv.URL, TLS: &TLS{Verify: true}}
+	assert.Error(t, NewClient(env).Check(ctx))
+
+	env.TLS = &TLS{Verify: true, CAFile: caFile}
+	assert.NoError(t, NewClient(env).Check(ctx))
+
+	env.TLS = &TLS{Verify: true, CAFile: filepath.Join(dir, "missing.pem")}
+	assert.Error(t, NewClient(env).Check(ctx))
+}
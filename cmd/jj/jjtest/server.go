@@ -0,0 +1,887 @@
+// Package jjtest provides an in-process fake Jenkins for offline tests of
+// the jj package and the commands built on top of it.
+package jjtest
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj"
+)
+
+// Script describes how every build of a job goes on
+type Script struct {
+	// QueuePolls is the number of queue item requests answered before
+	// the item gets an executor
+	QueuePolls int
+	// Log is released chunk by chunk, one chunk per console request,
+	// the build finishes when the last chunk has been released
+	Log []string
+	// Result of the build, SUCCESS when empty
+	Result string
+	// Duration of the build reported by the api in milliseconds
+	Duration int
+	// Endless builds keep running after the log is released until they
+	// are finished by Server.Finish
+	Endless bool
+}
+
+// Job is a fake job, its fields can be changed before the job is built
+type Job struct {
+	Name       string
+	Params     []jj.ParameterDefinitions
+	Script     Script
+	Downstream []string
+	// Label is the label expression the job is restricted to
+	Label string
+	// Timestamps turns on the api of the Timestamper plugin, line N of
+	// the log is written at second N
+	Timestamps bool
+	// GitValues are offered by the Git Parameter plugin by parameter names
+	GitValues map[string][]string
+	// Artifacts archived by every build, contents by relative paths
+	Artifacts map[string]string
+	// TestReport is published by every build when it is set
+	TestReport *jj.TestReport
+	// Stages make the job a Pipeline, a stage is running while the chunk of
+	// the log with its index is released and finishes with the next one
+	Stages []Stage
+	// Input pauses every build of a Pipeline job after its log is released
+	Input *Input
+	// LogStatus fails requests for the log with the http status when it is
+	// set, the builds still run
+	LogStatus int
+	builds    []*Build
+}
+
+// Input is an input step of a Pipeline
+type Input struct {
+	ID      string
+	Message string
+	Params  []jj.ParameterDefinitions
+}
+
+// Stage is a stage of every build of a Pipeline job
+type Stage struct {
+	Name string
+	// Status of the finished stage, SUCCESS when empty
+	Status string
+	// Parallel runs the stage together with the previous one
+	Parallel bool
+	Log      string
+}
+
+// Build is a started build of a job
+type Build struct {
+	Number  int
+	QueueId int
+	Params  url.Values
+	// Files holds contents of uploaded file parameters
+	Files      map[string]string
+	Result     string
+	Building   bool
+	Upstream   string
+	UpstreamId int
+	// Answer to the input step: "proceed" or "abort", empty while pending
+	Answer string
+	// InputValues are submitted with the input step
+	InputValues map[string]interface{}
+	released    int
+	log         string
+	script      Script
+}
+
+type queueItem struct {
+	id         int
+	job        *Job
+	params     url.Values
+	files      map[string]string
+	user       string
+	polls      int
+	cancelled  bool
+	build      *Build
+	upstream   string
+	upstreamId int
+	since      int64
+}
+
+// Server is a fake Jenkins served by httptest
+type Server struct {
+	*httptest.Server
+	mutex   sync.Mutex
+	jobs    map[string]*Job
+	views   map[string][]string
+	queue   []*queueItem
+	queueId int
+	// Requests counts requests by the url path
+	Requests map[string]int
+	// Executors by labels, the empty label holds all executors
+	Executors map[string]Executors
+	// QueueStatus fails requests for queue items with the http status when
+	// it is set
+	QueueStatus int
+}
+
+// Executors is the load of the nodes with a label
+type Executors struct {
+	Idle int
+	Busy int
+}
+
+func New() *Server {
+	s := &Server{
+		jobs:      map[string]*Job{},
+		views:     map[string][]string{"all": {}},
+		queueId:   100,
+		Requests:  map[string]int{},
+		Executors: map[string]Executors{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// builds of the fake are short, there is no reason to wait for them,
+// the pointer is shared to keep environments of the server equal
+var fastPolling = &jj.Polling{Min: time.Millisecond, Max: 10 * time.Millisecond}
+
+// Env returns an environment pointing to the server
+func (s *Server) Env() jj.Env {
+	return jj.Env{
+		Name:    "jjtest",
+		Url:     s.URL + "/",
+		Type:    "n",
+		Polling: fastPolling,
+	}
+}
+
+// AddJob adds the job to the "all" view, the name can be a path inside
+// folders like "team/app/main", the folders are created automatically
+func (s *Server) AddJob(name string, params ...jj.ParameterDefinitions) *Job {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	job := &Job{Name: name, Params: params}
+	s.jobs[name] = job
+	top := strings.Split(name, "/")[0]
+	for _, j := range s.views["all"] {
+		if j == top {
+			return job
+		}
+	}
+	s.views["all"] = append(s.views["all"], top)
+	return job
+}
+
+// AddView adds a view with the given top level jobs
+func (s *Server) AddView(name string, jobs ...string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.views[name] = jobs
+}
+
+// Builds returns all builds of the job
+func (s *Server) Builds(job string) []*Build {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]*Build{}, s.jobs[job].builds...)
+}
+
+// RequestCount returns the number of requests to the path, it can be
+// called while requests are served
+func (s *Server) RequestCount(path string) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.Requests[path]
+}
+
+// Finish finishes the build immediately
+func (s *Server) Finish(job string, number int, result string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	b := s.findBuild(s.jobs[job], number)
+	s.finish(s.jobs[job], b, result)
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.Requests[r.URL.Path]++
+	r.ParseForm()
+	r.ParseMultipartForm(1 << 20)
+
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case r.URL.Path == "/api/json":
+		s.writeRoot(w)
+	case segments[0] == "view" && len(segments) == 4:
+		s.writeView(w, segments[1])
+	case segments[0] == "queue":
+		s.handleQueue(w, r, segments[1:])
+	case segments[0] == "job":
+		s.handleJob(w, r, segments)
+	case r.URL.Path == "/computer/api/json":
+		s.writeExecutors(w, "")
+	case segments[0] == "label" && len(segments) == 4:
+		s.writeExecutors(w, segments[1])
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) writeRoot(w http.ResponseWriter) {
+	names := []string{}
+	for name := range s.views {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	views := []jj.View{}
+	for _, name := range names {
+		views = append(views, jj.View{Name: name, URL: s.URL + "/view/" + name + "/"})
+	}
+	writeJSON(w, map[string]interface{}{"views": views})
+}
+
+func (s *Server) writeView(w http.ResponseWriter, name string) {
+	names, ok := s.views[name]
+	if !ok {
+		w.WriteHeader(404)
+		return
+	}
+	jobs := []jj.Job{}
+	for _, name := range names {
+		jobs = append(jobs, s.item(name))
+	}
+	writeJSON(w, jj.View{Name: name, URL: s.URL + "/view/" + name + "/", Jobs: jobs})
+}
+
+// item describes a job or a folder at the path
+func (s *Server) item(path string) jj.Job {
+	item := jj.Job{
+		Name:  jj.JobPath(path).Name(),
+		URL:   s.URL + "/" + jj.JobPath(path).URL() + "/",
+		Class: "hudson.model.FreeStyleProject",
+	}
+	if _, ok := s.jobs[path]; !ok {
+		item.Class = "com.cloudbees.hudson.plugins.folder.Folder"
+	}
+	return item
+}
+
+func (s *Server) children(folder string) []jj.Job {
+	seen := map[string]bool{}
+	children := []jj.Job{}
+	names := []string{}
+	for name := range s.jobs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !strings.HasPrefix(name, folder+"/") {
+			continue
+		}
+		child := folder + "/" + strings.Split(strings.TrimPrefix(name, folder+"/"), "/")[0]
+		if !seen[child] {
+			seen[child] = true
+			children = append(children, s.item(child))
+		}
+	}
+	return children
+}
+
+func (s *Server) handleJob(w http.ResponseWriter, r *http.Request, segments []string) {
+	names := []string{}
+	i := 0
+	for ; i+1 < len(segments) && segments[i] == "job"; i += 2 {
+		names = append(names, segments[i+1])
+	}
+	path := strings.Join(names, "/")
+	rest := segments[i:]
+	job, ok := s.jobs[path]
+	if !ok {
+		if children := s.children(path); len(children) > 0 && len(rest) == 2 && rest[0] == "api" {
+			writeJSON(w, map[string]interface{}{"jobs": children})
+			return
+		}
+		w.WriteHeader(404)
+		return
+	}
+	if len(rest) == 0 {
+		w.WriteHeader(404)
+		return
+	}
+	switch rest[0] {
+	case "api":
+		s.writeJob(w, job)
+	case "build", "buildWithParameters":
+		item := s.enqueue(job, r.Form, "", 0)
+		item.files = uploadedFiles(r)
+		item.user, _, _ = r.BasicAuth()
+		w.Header().Set("Location", fmt.Sprintf("%s/queue/item/%d/", s.URL, item.id))
+		w.WriteHeader(201)
+	case "descriptorByName":
+		values := []map[string]string{}
+		for _, v := range job.GitValues[r.Form.Get("param")] {
+			values = append(values, map[string]string{"name": v, "value": v})
+		}
+		writeJSON(w, map[string]interface{}{"values": values})
+	default:
+		b := s.findBuild(job, buildNumber(job, rest[0]))
+		if b == nil || len(rest) < 2 {
+			w.WriteHeader(404)
+			return
+		}
+		s.handleBuild(w, r, job, b, rest[1:])
+	}
+}
+
+func (s *Server) handleBuild(w http.ResponseWriter, r *http.Request, job *Job, b *Build, rest []string) {
+	switch rest[0] {
+	case "api":
+		writeJSON(w, s.buildJSON(job, b))
+	case "logText":
+		start, _ := strconv.Atoi(r.Form.Get("start"))
+		s.release(job, b)
+		if job.LogStatus != 0 {
+			w.WriteHeader(job.LogStatus)
+			return
+		}
+		if start > len(b.log) {
+			start = len(b.log)
+		}
+		w.Header().Set("X-Text-Size", strconv.Itoa(len(b.log)))
+		if b.Building {
+			w.Header().Set("X-More-Data", "true")
+		}
+		fmt.Fprint(w, b.log[start:])
+	case "consoleText":
+		fmt.Fprint(w, b.log)
+	case "wfapi":
+		if len(job.Stages) == 0 || len(rest) != 2 {
+			w.WriteHeader(404)
+			return
+		}
+		switch rest[1] {
+		case "describe":
+			writeJSON(w, s.describeRun(job, b))
+		case "pendingInputActions":
+			writeJSON(w, pendingInputs(job, b))
+		case "inputSubmit":
+			values := map[string]interface{}{}
+			var submitted struct {
+				Parameter []struct {
+					Name  string
+					Value interface{}
+				}
+			}
+			json.Unmarshal([]byte(r.Form.Get("json")), &submitted)
+			for _, p := range submitted.Parameter {
+				values[p.Name] = p.Value
+			}
+			s.answer(w, job, b, r.Form.Get("inputId"), "proceed", values)
+		default:
+			w.WriteHeader(404)
+		}
+	case "input":
+		switch {
+		case len(rest) == 3 && rest[2] == "proceedEmpty":
+			s.answer(w, job, b, rest[1], "proceed", nil)
+		case len(rest) == 3 && rest[2] == "abort":
+			s.answer(w, job, b, rest[1], "abort", nil)
+		default:
+			w.WriteHeader(404)
+		}
+	case "execution":
+		s.writeNode(w, job, b, rest)
+	case "testReport":
+		if job.TestReport == nil {
+			w.WriteHeader(404)
+			return
+		}
+		writeJSON(w, job.TestReport)
+	case "artifact":
+		s.writeArtifact(w, r, job, strings.Join(rest[1:], "/"))
+	case "timestamps":
+		if !job.Timestamps {
+			w.WriteHeader(404)
+			return
+		}
+		s.release(job, b)
+		lines := strings.SplitAfter(b.log, "\n")
+		if lines[len(lines)-1] == "" {
+			lines = lines[:len(lines)-1]
+		}
+		start, _ := strconv.Atoi(r.Form.Get("startLine"))
+		switch {
+		case start < 0 && -start < len(lines):
+			start = len(lines) + start
+		case start < 0:
+			start = 0
+		case start > 0:
+			start--
+		}
+		for i := start; i < len(lines); i++ {
+			fmt.Fprintf(w, "00:00:%02d  %s", i+1, lines[i])
+		}
+	case "stop", "term", "kill":
+		if b.Building {
+			s.finish(job, b, "ABORTED")
+		}
+		w.WriteHeader(200)
+	default:
+		w.WriteHeader(404)
+	}
+}
+
+// release appends the next chunk of the scripted log
+func (s *Server) release(job *Job, b *Build) {
+	if b.Building && b.released < len(b.script.Log) {
+		b.log += b.script.Log[b.released]
+		b.released++
+	}
+	if b.Building && b.released == len(b.script.Log) && !paused(job, b) && !b.script.Endless {
+		s.finish(job, b, b.script.Result)
+	}
+}
+
+// stageSteps returns the step each stage runs at, parallel stages share
+// the step of the previous stage
+func stageSteps(job *Job) []int {
+	steps := make([]int, len(job.Stages))
+	for i, stage := range job.Stages {
+		if i > 0 {
+			steps[i] = steps[i-1]
+			if !stage.Parallel {
+				steps[i]++
+			}
+		}
+	}
+	return steps
+}
+
+// stageStatuses returns statuses of the stages as the build goes on
+func stageStatuses(job *Job, b *Build) []string {
+	statuses := make([]string, len(job.Stages))
+	for i, step := range stageSteps(job) {
+		switch {
+		case !b.Building || b.released > step:
+			statuses[i] = job.Stages[i].Status
+			if statuses[i] == "" {
+				statuses[i] = jj.StageSuccess
+			}
+		case b.released == step:
+			statuses[i] = jj.StageInProgress
+		default:
+			statuses[i] = jj.StageNotExecuted
+		}
+	}
+	return statuses
+}
+
+func stageJSON(job *Job, i int, status string) map[string]interface{} {
+	return map[string]interface{}{
+		"id":              strconv.Itoa(10 * (i + 1)),
+		"name":            job.Stages[i].Name,
+		"status":          status,
+		"startTimeMillis": 1000 * stageSteps(job)[i],
+		"durationMillis":  1000,
+	}
+}
+
+func (s *Server) describeRun(job *Job, b *Build) map[string]interface{} {
+	stages := []map[string]interface{}{}
+	for i, status := range stageStatuses(job, b) {
+		stages = append(stages, stageJSON(job, i, status))
+	}
+	status := jj.StageInProgress
+	if paused(job, b) && b.released == len(b.script.Log) {
+		status = jj.StagePaused
+	}
+	if !b.Building {
+		status = strings.Replace(b.Result, "FAILURE", jj.StageFailed, 1)
+	}
+	return map[string]interface{}{
+		"id":     strconv.Itoa(b.Number),
+		"name":   "#" + strconv.Itoa(b.Number),
+		"status": status,
+		"stages": stages,
+	}
+}
+
+// writeNode answers execution/node/ID/wfapi/describe of stages and
+// execution/node/ID/wfapi/log of flow nodes, stage N has id 10*(N+1)
+// and a single flow node with the next id
+func (s *Server) writeNode(w http.ResponseWriter, job *Job, b *Build, rest []string) {
+	if len(rest) != 5 || rest[1] != "node" || rest[3] != "wfapi" {
+		w.WriteHeader(404)
+		return
+	}
+	id, _ := strconv.Atoi(rest[2])
+	i := id/10 - 1
+	if i < 0 || i >= len(job.Stages) {
+		w.WriteHeader(404)
+		return
+	}
+	status := stageStatuses(job, b)[i]
+	switch {
+	case rest[4] == "describe" && id%10 == 0:
+		stage := stageJSON(job, i, status)
+		stage["stageFlowNodes"] = []map[string]interface{}{{
+			"id":     strconv.Itoa(id + 1),
+			"name":   "Shell Script",
+			"status": status,
+		}}
+		writeJSON(w, stage)
+	case rest[4] == "log" && id%10 == 1:
+		text := ""
+		if status != jj.StageNotExecuted {
+			text = job.Stages[i].Log
+		}
+		writeJSON(w, map[string]interface{}{
+			"nodeId":     rest[2],
+			"nodeStatus": status,
+			"length":     len(text),
+			"text":       text,
+		})
+	default:
+		w.WriteHeader(404)
+	}
+}
+
+func paused(job *Job, b *Build) bool {
+	return job.Input != nil && b.Building && b.Answer == ""
+}
+
+func pendingInputs(job *Job, b *Build) []map[string]interface{} {
+	if !paused(job, b) || b.released < len(b.script.Log) {
+		return []map[string]interface{}{}
+	}
+	inputs := []map[string]interface{}{}
+	for _, pd := range job.Input.Params {
+		inputs = append(inputs, map[string]interface{}{
+			"type":        pd.Type,
+			"name":        pd.Name,
+			"description": pd.Description,
+			"definition":  map[string]interface{}{"choices": pd.Choices, "defaultVal": pd.DefaultParameterValue.Value},
+		})
+	}
+	return []map[string]interface{}{{
+		"id":          job.Input.ID,
+		"message":     job.Input.Message,
+		"proceedText": "Proceed",
+		"inputs":      inputs,
+	}}
+}
+
+// answer proceeds or aborts the input step the build is paused on
+func (s *Server) answer(w http.ResponseWriter, job *Job, b *Build, id string, answer string, values map[string]interface{}) {
+	if len(pendingInputs(job, b)) == 0 || id != job.Input.ID {
+		w.WriteHeader(404)
+		return
+	}
+	b.Answer = answer
+	b.InputValues = values
+	if answer == "abort" {
+		s.finish(job, b, "ABORTED")
+	} else {
+		s.finish(job, b, b.script.Result)
+	}
+	w.WriteHeader(200)
+}
+
+// writeArtifact supports ranges and HEAD requests like the Jenkins does
+func (s *Server) writeArtifact(w http.ResponseWriter, r *http.Request, job *Job, path string) {
+	if path == "*zip*/archive.zip" {
+		w.Header().Set("Content-Type", "application/zip")
+		zw := zip.NewWriter(w)
+		for _, name := range sortedNames(job.Artifacts) {
+			f, _ := zw.Create("archive/" + name)
+			fmt.Fprint(f, job.Artifacts[name])
+		}
+		zw.Close()
+		return
+	}
+	content, ok := job.Artifacts[path]
+	if !ok {
+		w.WriteHeader(404)
+		return
+	}
+	http.ServeContent(w, r, path, time.Time{}, strings.NewReader(content))
+}
+
+func sortedNames(m map[string]string) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *Server) handleQueue(w http.ResponseWriter, r *http.Request, segments []string) {
+	switch {
+	case len(segments) == 2 && segments[0] == "api":
+		items := []map[string]interface{}{}
+		for _, item := range s.queue {
+			if item.build == nil && !item.cancelled {
+				items = append(items, s.queueJSON(item))
+			}
+		}
+		writeJSON(w, map[string]interface{}{"items": items})
+	case len(segments) == 1 && segments[0] == "cancelItem":
+		id, _ := strconv.Atoi(r.Form.Get("id"))
+		for _, item := range s.queue {
+			if item.id == id && item.build == nil {
+				item.cancelled = true
+			}
+		}
+		w.WriteHeader(302)
+	case len(segments) == 4 && segments[0] == "item":
+		if s.QueueStatus != 0 {
+			w.WriteHeader(s.QueueStatus)
+			return
+		}
+		id, _ := strconv.Atoi(segments[1])
+		for _, item := range s.queue {
+			if item.id == id {
+				if item.build == nil && !item.cancelled {
+					if item.polls >= item.job.Script.QueuePolls {
+						s.start(item)
+					}
+					item.polls++
+				}
+				writeJSON(w, s.queueJSON(item))
+				return
+			}
+		}
+		w.WriteHeader(404)
+	default:
+		w.WriteHeader(404)
+	}
+}
+
+func (s *Server) enqueue(job *Job, params url.Values, upstream string, upstreamId int) *queueItem {
+	s.queueId++
+	item := &queueItem{id: s.queueId, job: job, params: params, upstream: upstream, upstreamId: upstreamId,
+		since: time.Now().UnixNano() / int64(time.Millisecond)}
+	s.queue = append(s.queue, item)
+	return item
+}
+
+func (s *Server) start(item *queueItem) {
+	job := item.job
+	b := &Build{
+		Number:     len(job.builds) + 1,
+		QueueId:    item.id,
+		Params:     item.params,
+		Files:      item.files,
+		Building:   true,
+		Upstream:   item.upstream,
+		UpstreamId: item.upstreamId,
+		script:     job.Script,
+	}
+	job.builds = append(job.builds, b)
+	item.build = b
+	if len(b.script.Log) == 0 && !paused(job, b) {
+		s.finish(job, b, b.script.Result)
+	}
+}
+
+func (s *Server) finish(job *Job, b *Build, result string) {
+	if result == "" {
+		result = "SUCCESS"
+	}
+	b.Building = false
+	b.Result = result
+	if result != "SUCCESS" {
+		return
+	}
+	for _, name := range job.Downstream {
+		if child, ok := s.jobs[name]; ok {
+			s.enqueue(child, url.Values{}, job.Name, b.Number)
+		}
+	}
+}
+
+func uploadedFiles(r *http.Request) map[string]string {
+	files := map[string]string{}
+	if r.MultipartForm == nil {
+		return files
+	}
+	for name, headers := range r.MultipartForm.File {
+		f, err := headers[0].Open()
+		if err != nil {
+			continue
+		}
+		bin, _ := ioutil.ReadAll(f)
+		f.Close()
+		files[name] = string(bin)
+	}
+	return files
+}
+
+func (s *Server) findBuild(job *Job, number int) *Build {
+	if job == nil {
+		return nil
+	}
+	for _, b := range job.builds {
+		if b.Number == number {
+			return b
+		}
+	}
+	return nil
+}
+
+func buildNumber(job *Job, name string) int {
+	switch name {
+	case "lastBuild":
+		return len(job.builds)
+	case "lastSuccessfulBuild", "lastCompletedBuild":
+		for i := len(job.builds) - 1; i >= 0; i-- {
+			b := job.builds[i]
+			if !b.Building && (name == "lastCompletedBuild" || b.Result == "SUCCESS") {
+				return b.Number
+			}
+		}
+		return 0
+	}
+	number, _ := strconv.Atoi(name)
+	return number
+}
+
+func (s *Server) writeJob(w http.ResponseWriter, job *Job) {
+	downstream := []map[string]string{}
+	for _, name := range job.Downstream {
+		downstream = append(downstream, map[string]string{
+			"name": jj.JobPath(name).Name(),
+			"url":  s.URL + "/" + jj.JobPath(name).URL() + "/",
+		})
+	}
+	params := job.Params
+	if params == nil {
+		params = []jj.ParameterDefinitions{}
+	}
+	lastBuild := map[string]interface{}{}
+	if n := len(job.builds); n > 0 {
+		lastBuild = map[string]interface{}{"number": n, "url": fmt.Sprintf("%s/%s/%d/", s.URL, jj.JobPath(job.Name).URL(), n)}
+	}
+	builds := []map[string]interface{}{}
+	for i := len(job.builds) - 1; i >= 0; i-- {
+		builds = append(builds, s.buildJSON(job, job.builds[i]))
+	}
+	writeJSON(w, map[string]interface{}{
+		"name":               jj.JobPath(job.Name).Name(),
+		"fullName":           job.Name,
+		"url":                s.URL + "/" + jj.JobPath(job.Name).URL() + "/",
+		"nextBuildNumber":    len(job.builds) + 1,
+		"downstreamProjects": downstream,
+		"lastBuild":          lastBuild,
+		"builds":             builds,
+		"labelExpression":    job.Label,
+		"property":           []map[string]interface{}{{"parameterDefinitions": params}},
+	})
+}
+
+func (s *Server) writeExecutors(w http.ResponseWriter, label string) {
+	e, ok := s.Executors[label]
+	if !ok && label != "" {
+		w.WriteHeader(404)
+		return
+	}
+	rsp := map[string]interface{}{
+		"busyExecutors":  e.Busy,
+		"totalExecutors": e.Idle + e.Busy,
+	}
+	if label != "" {
+		rsp["name"] = label
+		rsp["idleExecutors"] = e.Idle
+	}
+	writeJSON(w, rsp)
+}
+
+func (s *Server) buildJSON(job *Job, b *Build) map[string]interface{} {
+	parameters := []map[string]string{}
+	for name := range b.Params {
+		parameters = append(parameters, map[string]string{"name": name, "value": b.Params.Get(name)})
+	}
+	actions := []map[string]interface{}{{"parameters": parameters}}
+	artifacts := []map[string]string{}
+	for _, name := range sortedNames(job.Artifacts) {
+		artifacts = append(artifacts, map[string]string{"fileName": name[strings.LastIndex(name, "/")+1:], "relativePath": name})
+	}
+	if b.Upstream != "" {
+		actions = append(actions, map[string]interface{}{"causes": []map[string]interface{}{{
+			"upstreamProject": b.Upstream,
+			"upstreamBuild":   b.UpstreamId,
+		}}})
+	}
+	var result interface{}
+	if !b.Building {
+		result = b.Result
+	}
+	return map[string]interface{}{
+		"id":        strconv.Itoa(b.Number),
+		"number":    b.Number,
+		"url":       fmt.Sprintf("%s/%s/%d/", s.URL, jj.JobPath(job.Name).URL(), b.Number),
+		"building":  b.Building,
+		"result":    result,
+		"duration":  b.script.Duration,
+		"queueId":   b.QueueId,
+		"actions":   actions,
+		"artifacts": artifacts,
+	}
+}
+
+func (s *Server) queueJSON(item *queueItem) map[string]interface{} {
+	parameters := []map[string]string{}
+	for name := range item.params {
+		parameters = append(parameters, map[string]string{"name": name, "value": item.params.Get(name)})
+	}
+	actions := []map[string]interface{}{{"parameters": parameters}}
+	if item.upstream != "" {
+		actions = append(actions, map[string]interface{}{"causes": []map[string]interface{}{{
+			"upstreamProject": item.upstream,
+			"upstreamBuild":   item.upstreamId,
+		}}})
+	}
+	if item.user != "" {
+		actions = append(actions, map[string]interface{}{"causes": []map[string]interface{}{{
+			"shortDescription": "Started by user " + item.user,
+			"userId":           item.user,
+			"userName":         item.user,
+		}}})
+	}
+	rsp := map[string]interface{}{
+		"id":           item.id,
+		"inQueueSince": item.since,
+		"cancelled":    item.cancelled,
+		"actions":      actions,
+		"task": map[string]string{
+			"name": jj.JobPath(item.job.Name).Name(),
+			"url":  s.URL + "/" + jj.JobPath(item.job.Name).URL() + "/",
+		},
+		"url": fmt.Sprintf("queue/item/%d/", item.id),
+	}
+	if item.build == nil {
+		rsp["why"] = "Waiting for next available executor"
+	} else {
+		rsp["executable"] = map[string]interface{}{
+			"number": item.build.Number,
+			"url":    fmt.Sprintf("%s/%s/%d/", s.URL, jj.JobPath(item.job.Name).URL(), item.build.Number),
+		}
+	}
+	return rsp
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
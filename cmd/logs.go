@@ -0,0 +1,282 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj"
+	"github.com/spf13/cobra"
+)
+
+type logsOptions struct {
+	follow      bool
+	tail        int
+	sinceOffset int64
+	output      string
+	timestamps  bool
+}
+
+func init() {
+	var opts logsOptions
+	logsCmd := &cobra.Command{
+		Use:   "logs JOB [BUILD|lastBuild]",
+		Short: "输出或下载构建的控制台日志",
+		Long: `输出构建（默认为最后一次构建）的控制台日志，日志按块读取，不会整个保存在内存中。
+安装了 Timestamper 插件时每行日志前会显示时间，--since-offset 按字节位置读取时不显示时间。`,
+		Run: func(cmd *cobra.Command, args []string) {
+			env := mustInit(ENV)
+			job, err := selectJob(env, args[0])
+			check(err)
+			build := "lastBuild"
+			if len(args) > 1 {
+				build = args[1]
+			}
+			check(showLogs(env, job, build, opts))
+		},
+		Args: cobra.RangeArgs(1, 2),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 1 {
+				if err := checkBuild(args[1]); err != nil {
+					return err
+				}
+			}
+			if opts.tail < 0 || opts.sinceOffset < 0 {
+				return errors.New("--tail and --since-offset should not be negative")
+			}
+			if opts.tail > 0 && opts.sinceOffset > 0 {
+				return errors.New("--tail and --since-offset can not be used together")
+			}
+			return preRunE(cmd, args)
+		},
+	}
+	logsCmd.Flags().StringVarP(&ENV, "name", "n", "", "current Jenkins name")
+	logsCmd.Flags().BoolVarP(&opts.follow, "follow", "f", false, "follow the log until the build finishes")
+	logsCmd.Flags().IntVar(&opts.tail, "tail", 0, "show only the last N lines")
+	logsCmd.Flags().Int64Var(&opts.sinceOffset, "since-offset", 0, "start from the byte offset of the log")
+	// 没有短选项，覆盖全局的 -o/--output
+	logsCmd.Flags().StringVar(&opts.output, "output", "", "write the log to the file instead of stdout")
+	logsCmd.Flags().BoolVar(&opts.timestamps, "timestamps", true, "show timestamps of the Timestamper plugin when it is installed")
+	rootCmd.AddCommand(logsCmd)
+}
+
+func showLogs(env jj.Env, job string, build string, opts logsOptions) error {
+	number, err := buildNumber(env, job, build)
+	if err != nil {
+		return err
+	}
+	var out io.Writer = os.Stdout
+	if opts.output != "" {
+		f, err := os.Create(opts.output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	if opts.timestamps && opts.sinceOffset == 0 {
+		err = timestampedLog(env, job, number, out, opts)
+		if !errors.Is(err, jj.ErrNotFound) {
+			return err
+		}
+		// 没有安装 Timestamper 插件
+	}
+	return plainLog(env, job, number, out, opts)
+}
+
+// checkBuild 检查构建号参数，可以是 lastBuild
+func checkBuild(build string) error {
+	if build == "lastBuild" {
+		return nil
+	}
+	if _, err := strconv.Atoi(build); err != nil {
+		return fmt.Errorf("无效的构建号: %s", build)
+	}
+	return nil
+}
+
+// buildNumber 返回构建号，build 可以是 lastBuild
+func buildNumber(env jj.Env, job string, build string) (int, error) {
+	if build != "lastBuild" {
+		return strconv.Atoi(build)
+	}
+	bi, err := jj.GetLastBuildInfo(env, job)
+	if errors.Is(err, jj.ErrNotFound) {
+		return 0, fmt.Errorf("任务 %s 还没有构建", job)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return bi.Number, nil
+}
+
+// plainLog 按字节位置读取日志，跟踪时直到 Jenkins 不再返回 X-More-Data
+func plainLog(env jj.Env, job string, number int, out io.Writer, opts logsOptions) error {
+	w := out
+	var tw *tailWriter
+	if opts.tail > 0 {
+		tw = newTailWriter(opts.tail)
+		w = tw
+	}
+	part, err := jj.StreamConsole(env, job, number, opts.sinceOffset, w)
+	if err != nil {
+		return err
+	}
+	if tw != nil {
+		if err := tw.flush(out); err != nil {
+			return err
+		}
+	}
+	poll := env.Polling.Backoff()
+	for opts.follow && part.More {
+		poll.Sleep()
+		offset := part.Next
+		part, err = jj.StreamConsole(env, job, number, offset, out)
+		if jj.IsTemporary(err) {
+			part = &jj.LogPart{Next: offset, More: true}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if part.Next != offset {
+			poll.Reset()
+		}
+	}
+	return nil
+}
+
+// timestampedLog 读取带时间的日志。Timestamper 只支持按行读取，
+// 跟踪时只输出完整的行，不完整的最后一行在下一次请求中重新读取
+func timestampedLog(env jj.Env, job string, number int, out io.Writer, opts logsOptions) error {
+	if !opts.follow {
+		startLine := 0
+		if opts.tail > 0 {
+			startLine = -opts.tail
+		}
+		return jj.StreamTimestamps(env, job, number, startLine, out)
+	}
+	var w io.Writer = out
+	var tw *tailWriter
+	if opts.tail > 0 {
+		tw = newTailWriter(opts.tail)
+		w = tw
+	}
+	lines := 0
+	poll := env.Polling.Backoff()
+	for {
+		bi, err := jj.GetBuildInfo(env, job, number)
+		if jj.IsTemporary(err) {
+			poll.Sleep()
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		lw := &lineWriter{w: w}
+		// Timestamper 的行号从 1 开始
+		err = jj.StreamTimestamps(env, job, number, lines+1, lw)
+		if err != nil && !jj.IsTemporary(err) {
+			return err
+		}
+		lines += lw.lines
+		done := err == nil && !bi.Building
+		if done {
+			// 构建已结束，日志是完整的
+			if err := lw.flush(); err != nil {
+				return err
+			}
+		}
+		if tw != nil {
+			if err := tw.flush(out); err != nil {
+				return err
+			}
+			w, tw = out, nil
+		}
+		if done {
+			return nil
+		}
+		if lw.lines > 0 {
+			poll.Reset()
+		}
+		poll.Sleep()
+	}
+}
+
+// lineWriter 只写出完整的行，不完整的行留到 flush
+type lineWriter struct {
+	w       io.Writer
+	lines   int
+	partial []byte
+}
+
+func (l *lineWriter) Write(p []byte) (int, error) {
+	l.partial = append(l.partial, p...)
+	i := bytes.LastIndexByte(l.partial, '\n')
+	if i < 0 {
+		return len(p), nil
+	}
+	if _, err := l.w.Write(l.partial[:i+1]); err != nil {
+		return 0, err
+	}
+	l.lines += bytes.Count(l.partial[:i+1], []byte{'\n'})
+	l.partial = append(l.partial[:0], l.partial[i+1:]...)
+	return len(p), nil
+}
+
+func (l *lineWriter) flush() error {
+	if len(l.partial) == 0 {
+		return nil
+	}
+	_, err := l.w.Write(l.partial)
+	l.partial = nil
+	return err
+}
+
+// tailWriter 只保留最后 n 行
+type tailWriter struct {
+	n       int
+	lines   [][]byte
+	partial []byte
+}
+
+func newTailWriter(n int) *tailWriter {
+	return &tailWriter{n: n}
+}
+
+func (t *tailWriter) Write(p []byte) (int, error) {
+	for _, b := range p {
+		t.partial = append(t.partial, b)
+		if b != '\n' {
+			continue
+		}
+		if len(t.lines) == t.n {
+			// 复用最早一行的空间
+			t.partial, t.lines = t.lines[0][:0], append(t.lines[1:], t.partial)
+		} else {
+			t.lines, t.partial = append(t.lines, t.partial), nil
+		}
+	}
+	return len(p), nil
+}
+
+func (t *tailWriter) flush(w io.Writer) error {
+	lines := t.lines
+	if len(t.partial) > 0 {
+		// 不完整的最后一行也算一行
+		if len(lines) == t.n {
+			lines = lines[1:]
+		}
+		lines = append(lines, t.partial)
+	}
+	for _, line := range lines {
+		if _, err := w.Write(line); err != nil {
+			return err
+		}
+	}
+	t.lines, t.partial = nil, nil
+	return nil
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj/jjtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTailWriter(t *testing.T) {
+	tests := []struct {
+		chunks []string
+		n      int
+		want   string
+	}{
+		{[]string{"a\nb\nc\n"}, 2, "b\nc\n"},
+		{[]string{"a\nb", "\nc"}, 2, "b\nc"},
+		{[]string{"a\n"}, 3, "a\n"},
+		{[]string{"a\nb\nc\nd\ne\n"}, 1, "e\n"},
+	}
+	for _, test := range tests {
+		tw := newTailWriter(test.n)
+		for _, chunk := range test.chunks {
+			tw.Write([]byte(chunk))
+		}
+		var buf bytes.Buffer
+		assert.NoError(t, tw.flush(&buf))
+		assert.Equal(t, test.want, buf.String())
+	}
+}
+
+func TestLineWriter(t *testing.T) {
+	var buf bytes.Buffer
+	lw := &lineWriter{w: &buf}
+	lw.Write([]byte("a\nb"))
+	lw.Write([]byte("c\nd"))
+	assert.Equal(t, "a\nbc\n", buf.String())
+	assert.Equal(t, 2, lw.lines)
+	assert.NoError(t, lw.flush())
+	assert.Equal(t, "a\nbc\nd", buf.String())
+}
+
+func TestShowLogs(t *testing.T) {
+	srv := jjtest.New()
+	defer srv.Close()
+	job := srv.AddJob("app")
+	job.Script = jjtest.Script{Log: []string{"line 1\n", "line 2\nline ", "3\n"}}
+	env := srv.Env()
+	startBuild(t, env, "app")
+
+	dir, err := ioutil.TempDir("", "jj")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.log")
+
+	// the plugin is not installed, the plain log is followed till the end
+	assert.NoError(t, showLogs(env, "app", "lastBuild", logsOptions{follow: true, output: path, timestamps: true}))
+	bin, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "line 1\nline 2\nline 3\n", string(bin))
+
+	assert.NoError(t, showLogs(env, "app", "1", logsOptions{tail: 2, sinceOffset: 0, output: path}))
+	bin, _ = ioutil.ReadFile(path)
+	assert.Equal(t, "line 2\nline 3\n", string(bin))
+
+	assert.NoError(t, showLogs(env, "app", "1", logsOptions{sinceOffset: 7, output: path}))
+	bin, _ = ioutil.ReadFile(path)
+	assert.Equal(t, "line 2\nline 3\n", string(bin))
+
+	job.Timestamps = true
+	assert.NoError(t, showLogs(env, "app", "1", logsOptions{tail: 1, output: path, timestamps: true}))
+	bin, _ = ioutil.ReadFile(path)
+	assert.Equal(t, "00:00:03  line 3\n", string(bin))
+
+	// a running build is followed line by line
+	job.Script = jjtest.Script{Log: []string{"step 1\nst", "ep 2\n", "step 3\n"}}
+	number := startBuild(t, env, "app")
+	assert.NoError(t, showLogs(env, "app", "2", logsOptions{follow: true, tail: 5, output: path, timestamps: true}))
+	bin, _ = ioutil.ReadFile(path)
+	assert.Equal(t, 2, number)
+	assert.Equal(t, "00:00:01  step 1\n00:00:02  step 2\n00:00:03  step 3\n", string(bin))
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v2"
+)
+
+var outputFormat string
+
+const outputUsage = "output format: json|yaml|wide|go-template=TEMPLATE"
+
+func validateOutput() error {
+	switch {
+	case outputFormat == "", outputFormat == "wide", outputFormat == "json", outputFormat == "yaml":
+		return nil
+	case strings.HasPrefix(outputFormat, "go-template="):
+		_, err := template.New("output").Parse(strings.TrimPrefix(outputFormat, "go-template="))
+		return err
+	}
+	return fmt.Errorf("unknown output format '%s', %s", outputFormat, outputUsage)
+}
+
+// printOutput prints v in the format given by -o, table prints the
+// default and the wide formats
+func printOutput(v interface{}, table func(w io.Writer, wide bool)) error {
+	return writeOutput(os.Stdout, v, table)
+}
+
+func writeOutput(w io.Writer, v interface{}, table func(w io.Writer, wide bool)) error {
+	if outputFormat == "" || outputFormat == "wide" {
+		table(w, outputFormat == "wide")
+		return nil
+	}
+	// yaml and templates use the same keys as json
+	data, err := toGeneric(v)
+	if err != nil {
+		return err
+	}
+	switch {
+	case outputFormat == "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(data)
+	case outputFormat == "yaml":
+		out, err := yaml.Marshal(data)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(out)
+		return err
+	case strings.HasPrefix(outputFormat, "go-template="):
+		tmpl, err := template.New("output").Parse(strings.TrimPrefix(outputFormat, "go-template="))
+		if err != nil {
+			return err
+		}
+		if err := tmpl.Execute(w, data); err != nil {
+			return err
+		}
+		fmt.Fprintln(w)
+		return nil
+	}
+	return validateOutput()
+}
+
+func toGeneric(v interface{}) (interface{}, error) {
+	bin, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	// numbers are kept as they are, float64 would print timestamps as 1.6e+12
+	decoder := json.NewDecoder(bytes.NewReader(bin))
+	decoder.UseNumber()
+	var data interface{}
+	if err := decoder.Decode(&data); err != nil {
+		return nil, err
+	}
+	return convertNumbers(data), nil
+}
+
+func convertNumbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for key, val := range v {
+			v[key] = convertNumbers(val)
+		}
+	case []interface{}:
+		for i, val := range v {
+			v[i] = convertNumbers(val)
+		}
+	}
+	return v
+}
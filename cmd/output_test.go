@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteOutput(t *testing.T) {
+	jobs := []jj.Job{{Name: "app", URL: "http://jenkins/job/app/"}, {Name: "team/web", URL: "http://jenkins/job/team/job/web/"}}
+	table := func(w io.Writer, wide bool) {
+		if wide {
+			io.WriteString(w, "wide")
+		} else {
+			io.WriteString(w, "table")
+		}
+	}
+	tcases := []struct {
+		format string
+		want   string
+	}{
+		{"", "table"},
+		{"wide", "wide"},
+		{"json", `[
+  {
+    "_class": "",
+    "name": "app",
+    "url": "http://jenkins/job/app/"
+  },
+  {
+    "_class": "",
+    "name": "team/web",
+    "url": "http://jenkins/job/team/job/web/"
+  }
+]
+`},
+		{"yaml", `- _class: ""
+  name: app
+  url: http://jenkins/job/app/
+- _class: ""
+  name: team/web
+  url: http://jenkins/job/team/job/web/
+`},
+		{"go-template={{range .}}{{.name}} {{end}}", "app team/web \n"},
+	}
+	defer func() { outputFormat = "" }()
+	for _, tc := range tcases {
+		outputFormat = tc.format
+		assert.NoError(t, validateOutput(), tc.format)
+		var out bytes.Buffer
+		assert.NoError(t, writeOutput(&out, jobs, table), tc.format)
+		assert.Equal(t, tc.want, out.String(), tc.format)
+	}
+
+	// big numbers are not printed in the exponent form
+	builds := []jj.BuildSummary{{Number: 1000000, Result: "SUCCESS", Timestamp: 1602835200123, Duration: 2500000}}
+	outputFormat = "yaml"
+	var out bytes.Buffer
+	assert.NoError(t, writeOutput(&out, builds, table))
+	assert.Contains(t, out.String(), "number: 1000000\n")
+	assert.Contains(t, out.String(), "timestamp: 1602835200123\n")
+	assert.Contains(t, out.String(), "duration: 2500000\n")
+	outputFormat = "go-template={{range .}}{{.number}} {{.timestamp}} {{.duration}}{{end}}"
+	out.Reset()
+	assert.NoError(t, writeOutput(&out, builds, table))
+	assert.Equal(t, "1000000 1602835200123 2500000\n", out.String())
+
+	outputFormat = "xml"
+	assert.Error(t, validateOutput())
+	outputFormat = "go-template={{.name"
+	assert.Error(t, validateOutput())
+}
@@ -0,0 +1,245 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/chzyer/readline"
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj"
+	"github.com/ttacon/chalk"
+)
+
+// askParam 按参数类型询问参数值，输入不合法时重新询问
+func askParam(env jj.Env, job string, pd jj.ParameterDefinitions) string {
+	label := chalk.Underline.TextStyle(pd.Name)
+	defVal := pd.DefaultParameterValue.Value
+	switch pd.Kind() {
+	case jj.ParamChoice:
+		return askChoice(pd.Name, pd.Choices, defVal)
+	case jj.ParamGit:
+		values, err := jj.GitParameterValues(env, job, pd.Name)
+		if err == nil && len(values) > 0 {
+			return askChoice(pd.Name, values, defVal)
+		}
+		fmt.Printf("无法获取 %s 的分支和标签: %v\n", pd.Name, err)
+	case jj.ParamBool:
+		def := "n"
+		if defVal == "true" {
+			def = "y"
+		}
+		for {
+			val, err := pd.Normalize(getAnswer(label+" (y/n): ", def, []string{"y", "n"}))
+			if err == nil {
+				return val
+			}
+			fmt.Println(err)
+		}
+	case jj.ParamPassword:
+		mustBeInteractive(pd.Name)
+		line, err := readline.Password(label + " (输入不显示，留空使用默认值): ")
+		if err != nil {
+			os.Exit(1)
+		}
+		if len(line) == 0 {
+			return defVal
+		}
+		return string(line)
+	case jj.ParamText:
+		mustBeInteractive(pd.Name)
+		fmt.Printf("%s: 在编辑器中输入多行文本...\n", label)
+		text, err := editText(defVal)
+		check(err)
+		return text
+	case jj.ParamFile:
+		for {
+			val, err := pd.Normalize(getBaseAnswer(label+" (文件路径): ", defVal))
+			if err == nil {
+				return val
+			}
+			fmt.Println(err)
+		}
+	case jj.ParamRun:
+		choices := []string{}
+		if builds, err := jj.GetBuilds(env, pd.ProjectName); err == nil {
+			for _, b := range builds {
+				choices = append(choices, pd.ProjectName+"#"+strconv.Itoa(b.Number))
+			}
+		}
+		if defVal == "" && len(choices) > 0 {
+			defVal = choices[0]
+		}
+		for {
+			val, err := pd.Normalize(getAnswer(label+" (JOB#NUMBER): ", defVal, choices))
+			if err == nil {
+				return val
+			}
+			fmt.Println(err)
+		}
+	}
+	return getOptionalAnswer(label+": ", defVal)
+}
+
+// editText 在 $VISUAL 或 $EDITOR（默认 vi）中编辑文本
+func editText(text string) (string, error) {
+	f, err := ioutil.TempFile("", "jj-*.txt")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(text)
+	f.Close()
+	if err != nil {
+		return "", err
+	}
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	// 编辑器可以带参数，例如 "code -w"
+	cmd := exec.Command("sh", "-c", editor+` "$1"`, "sh", f.Name())
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("编辑器 %s 运行失败: %w", editor, err)
+	}
+	bin, err := ioutil.ReadFile(f.Name())
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(bin), "\n"), nil
+}
+
+// mergeParams 合并参数值，优先级: 默认值 < 预设 < 参数文件 < -a
+func mergeParams(params []jj.ParameterDefinitions, preset, file map[string]string) (map[string]string, error) {
+	raw, err := rawParams(params, preset, file)
+	if err != nil {
+		return nil, err
+	}
+	data := map[string]string{}
+	for _, pd := range params {
+		data[pd.Name] = pd.DefaultParameterValue.Value
+		val, ok := raw[pd.Name]
+		if !ok {
+			continue
+		}
+		if data[pd.Name], err = resolveParam(pd, val); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// rawParams 返回预设、参数文件和 -a 指定的值，@path 和 env:VAR 等引用还没有解析
+func rawParams(params []jj.ParameterDefinitions, preset, file map[string]string) (map[string]string, error) {
+	for name := range file {
+		if !definedParam(params, name) {
+			return nil, fmt.Errorf("unknown parameter %s in %s", name, paramsSource(paramsFile))
+		}
+	}
+	raw := map[string]string{}
+	for _, pd := range params {
+		val, ok := preset[pd.Name]
+		if v, found := file[pd.Name]; found {
+			val, ok = v, true
+		}
+		if arg, err := inputArgs.get(pd.Name); err == nil {
+			val, ok = arg, true
+		}
+		if ok {
+			raw[pd.Name] = val
+		}
+	}
+	return raw, nil
+}
+
+// resolveParam 解析参数值中的引用
+func resolveParam(pd jj.ParameterDefinitions, val string) (string, error) {
+	// 文件参数的值本身就是路径，@path 等同于 path
+	if pd.Kind() == jj.ParamFile && strings.HasPrefix(val, "@") {
+		return val[1:], nil
+	}
+	val, err := resolveValue(val)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", pd.Name, err)
+	}
+	return val, nil
+}
+
+// checkParams 按参数定义校验并规范化参数值，文件参数单独返回
+func checkParams(env jj.Env, job string, params []jj.ParameterDefinitions, data map[string]string) (url.Values, map[string]string, error) {
+	values := url.Values{}
+	files := map[string]string{}
+	for _, pd := range params {
+		val, ok := data[pd.Name]
+		if !ok {
+			continue
+		}
+		if pd.Kind() == jj.ParamFile && val == "" {
+			// 未指定文件时不上传
+			continue
+		}
+		val, err := pd.Normalize(val)
+		if err != nil {
+			return nil, nil, err
+		}
+		if pd.Kind() == jj.ParamGit {
+			// 只有成功获取到列表时才校验
+			if choices, err := jj.GitParameterValues(env, job, pd.Name); err == nil && len(choices) > 0 && !contains(choices, val) {
+				return nil, nil, fmt.Errorf("%s: '%s' is not one of %s", pd.Name, val, strings.Join(choices, ", "))
+			}
+		}
+		if pd.Kind() == jj.ParamFile {
+			files[pd.Name] = val
+			continue
+		}
+		values.Set(pd.Name, val)
+	}
+	// 不在参数定义中的值原样提交
+	for name, val := range data {
+		if !definedParam(params, name) {
+			values.Set(name, val)
+		}
+	}
+	return values, files, nil
+}
+
+// submitBuild 校验参数并触发构建，返回队列号
+func submitBuild(env jj.Env, job string, params []jj.ParameterDefinitions, data map[string]string) (int, error) {
+	values, files, err := checkParams(env, job, params, data)
+	if err != nil {
+		return 0, err
+	}
+	if len(files) > 0 {
+		return jj.BuildWithFiles(env, job, values, files)
+	}
+	err, queueId := jj.Build(env, job, values.Encode())
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(queueId)
+}
+
+func definedParam(params []jj.ParameterDefinitions, name string) bool {
+	for _, pd := range params {
+		if pd.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, val string) bool {
+	for _, v := range list {
+		if v == val {
+			return true
+		}
+	}
+	return false
+}
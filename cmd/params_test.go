@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj"
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj/jjtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubmitBuild(t *testing.T) {
+	srv := jjtest.New()
+	defer srv.Close()
+	params := []jj.ParameterDefinitions{
+		{Name: "DEBUG", Type: "BooleanParameterDefinition"},
+		{Name: "BRANCH", Type: "PT_BRANCH"},
+		{Name: "PACKAGE", Type: "FileParameterDefinition"},
+	}
+	srv.AddJob("app", params...).GitValues = map[string][]string{"BRANCH": {"master", "develop"}}
+	env := useServer(t, srv)
+
+	dir, err := ioutil.TempDir("", "jj")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "app.tar")
+	assert.NoError(t, ioutil.WriteFile(file, []byte("content"), 0644))
+
+	_, err = submitBuild(env, "app", params, map[string]string{"DEBUG": "y", "BRANCH": "feature"})
+	assert.EqualError(t, err, "BRANCH: 'feature' is not one of master, develop")
+	_, err = submitBuild(env, "app", params, map[string]string{"DEBUG": "maybe"})
+	assert.Error(t, err)
+	assert.Len(t, srv.Builds("app"), 0)
+
+	queueId, err := submitBuild(env, "app", params, map[string]string{"DEBUG": "y", "BRANCH": "develop", "PACKAGE": file})
+	assert.NoError(t, err)
+	err, _ = jj.GetQueueInfo(env, queueId)
+	assert.NoError(t, err)
+	builds := srv.Builds("app")
+	assert.Len(t, builds, 1)
+	assert.Equal(t, "true", builds[0].Params.Get("DEBUG"))
+	assert.Equal(t, "develop", builds[0].Params.Get("BRANCH"))
+	assert.Equal(t, "content", builds[0].Files["PACKAGE"])
+}
+
+func TestReadParams(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jj")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	want := map[string]string{"BRANCH": "master", "DEBUG": "true", "COUNT": "3", "NOTES": "line 1\nline 2"}
+	files := map[string]string{
+		"params.yaml": "BRANCH: master\nDEBUG: true\nCOUNT: 3\nNOTES: |-\n  line 1\n  line 2\n",
+		"params.json": `{"BRANCH": "master", "DEBUG": true, "COUNT": 3, "NOTES": "line 1\nline 2"}`,
+		".env":        "# comment\nexport BRANCH=master\nDEBUG='true'\nCOUNT = 3\nNOTES=\"line 1\\nline 2\"\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+		params, err := readParams(path, nil)
+		assert.NoError(t, err, name)
+		assert.Equal(t, want, params, name)
+
+		// the format of stdin is guessed by the content
+		params, err = readParams("-", strings.NewReader(content))
+		assert.NoError(t, err, name)
+		assert.Equal(t, want, params, name)
+	}
+
+	_, err = readParams("-", strings.NewReader("BRANCH: [master]"))
+	assert.Error(t, err)
+
+	// numbers are submitted as they are written
+	params, err := readParams("-", strings.NewReader(`{"COUNT": 1000000, "VERSION": 1.10, "RATIO": 2.5e3}`))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"COUNT": "1000000", "VERSION": "1.10", "RATIO": "2.5e3"}, params)
+	params, err = readParams("-", strings.NewReader("COUNT: 1000000\nVERSION: 1.10\nDEBUG: yes\nEMPTY:\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"COUNT": "1000000", "VERSION": "1.10", "DEBUG": "yes", "EMPTY": ""}, params)
+}
+
+func TestResolveValue(t *testing.T) {
+	os.Setenv("JJ_TEST_TOKEN", "secret")
+	defer os.Unsetenv("JJ_TEST_TOKEN")
+	tcases := []struct {
+		val  string
+		want string
+	}{
+		{"master", "master"},
+		{"env:JJ_TEST_TOKEN", "secret"},
+		{`\env:JJ_TEST_TOKEN`, "env:JJ_TEST_TOKEN"},
+		{`\\env:JJ_TEST_TOKEN`, `\env:JJ_TEST_TOKEN`},
+		{"@@team", "@team"},
+		{"env:not a reference", "env:not a reference"},
+	}
+	for _, tc := range tcases {
+		t.Run(tc.val, func(t *testing.T) {
+			val, err := resolveValue(tc.val)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, val)
+			// escaped values are resolved to themselves
+			val, err = resolveValue(escapeValue(tc.want))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, val)
+		})
+	}
+}
+
+func TestMergeParams(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jj")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	notes := filepath.Join(dir, "notes.txt")
+	assert.NoError(t, ioutil.WriteFile(notes, []byte("line 1\nline 2\n"), 0644))
+	os.Setenv("JJ_TEST_TOKEN", "secret")
+	defer os.Unsetenv("JJ_TEST_TOKEN")
+
+	branch := jj.ParameterDefinitions{Name: "BRANCH", Type: "StringParameterDefinition"}
+	branch.DefaultParameterValue.Value = "master"
+	params := []jj.ParameterDefinitions{
+		branch,
+		{Name: "TARGET", Type: "StringParameterDefinition"},
+		{Name: "NOTES", Type: "TextParameterDefinition"},
+		{Name: "TOKEN", Type: "PasswordParameterDefinition"},
+		{Name: "PACKAGE", Type: "FileParameterDefinition"},
+		{Name: "EMAIL", Type: "StringParameterDefinition"},
+	}
+	inputArgs = arguments{args: []string{"TARGET=prod", "NOTES=@" + notes, "PACKAGE=@./app.tar", "EMAIL=@@team"}}
+	defer func() { inputArgs = arguments{} }()
+
+	data, err := mergeParams(params, map[string]string{"TARGET": "uat"}, map[string]string{"TARGET": "dev", "TOKEN": "env:JJ_TEST_TOKEN"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"BRANCH":  "master",
+		"TARGET":  "prod",
+		"NOTES":   "line 1\nline 2\n",
+		"TOKEN":   "secret",
+		"PACKAGE": "./app.tar",
+		"EMAIL":   "@team",
+	}, data)
+
+	_, err = mergeParams(params, nil, map[string]string{"BRANHC": "develop"})
+	assert.Error(t, err)
+	_, err = mergeParams(params, nil, map[string]string{"TOKEN": "env:JJ_TEST_MISSING"})
+	assert.EqualError(t, err, "TOKEN: environment variable JJ_TEST_MISSING is not set")
+}
@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj"
+	"github.com/spf13/cobra"
+)
+
+var presetName string
+var savePresetName string
+
+func init() {
+	presetsCmd := &cobra.Command{
+		Use:   "presets",
+		Short: "管理任务的参数预设",
+		Long: `管理任务的参数预设，预设按 Jenkins 环境和任务保存在 ~/.jj/presets.yaml 中。
+使用 jj run JOB -a key=val --save-preset NAME 保存预设，
+使用 jj run JOB --preset NAME 按预设运行任务。`,
+		Args: cobra.NoArgs,
+	}
+	listCmd := &cobra.Command{
+		Use:     "list [JOB]",
+		Aliases: []string{"ls"},
+		Short:   "列出预设",
+		Run: func(cmd *cobra.Command, args []string) {
+			job := ""
+			if len(args) > 0 {
+				job = args[0]
+			}
+			listPresets(presetsEnv(), job)
+		},
+		Args:    cobra.MaximumNArgs(1),
+		PreRunE: preRunE,
+	}
+	showCmd := &cobra.Command{
+		Use:   "show JOB NAME",
+		Short: "显示预设的参数",
+		Run: func(cmd *cobra.Command, args []string) {
+			showPreset(presetsEnv(), args[0], args[1])
+		},
+		Args:    cobra.ExactArgs(2),
+		PreRunE: preRunE,
+	}
+	deleteCmd := &cobra.Command{
+		Use:     "delete JOB NAME",
+		Aliases: []string{"rm"},
+		Short:   "删除预设",
+		Run: func(cmd *cobra.Command, args []string) {
+			env := presetsEnv()
+			check(jj.DefaultPresetStore.Delete(env.Name, args[0], args[1]))
+			fmt.Printf("已删除预设 %s\n", args[1])
+		},
+		Args:    cobra.ExactArgs(2),
+		PreRunE: preRunE,
+	}
+	for _, c := range []*cobra.Command{listCmd, showCmd, deleteCmd} {
+		c.Flags().StringVarP(&ENV, "name", "n", "", "current Jenkins name")
+		presetsCmd.AddCommand(c)
+	}
+	rootCmd.AddCommand(presetsCmd)
+}
+
+// 预设只在本地保存，不需要访问 Jenkins
+func presetsEnv() jj.Env {
+	err, env := jj.GetEnv(ENV)
+	check(err)
+	return env
+}
+
+func listPresets(env jj.Env, job string) {
+	presets, err := jj.DefaultPresetStore.List(env.Name, job)
+	check(err)
+	check(printOutput(presets, func(out io.Writer, wide bool) {
+		if len(presets) == 0 {
+			fmt.Fprintln(out, "没有保存的预设")
+			return
+		}
+		w := new(tabwriter.Writer)
+		w.Init(out, 0, 8, 0, '\t', 0)
+		if !noheader {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", "Job", "Name", "Params")
+		}
+		for _, p := range presets {
+			params := fmt.Sprint(len(p.Params))
+			if wide {
+				params = formatParams(p.Params)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", p.Job, p.Name, params)
+		}
+		fmt.Fprintln(w)
+		w.Flush()
+	}))
+}
+
+func showPreset(env jj.Env, job, name string) {
+	p, err := jj.DefaultPresetStore.Get(env.Name, job, name)
+	check(err)
+	check(printOutput(p, func(w io.Writer, wide bool) {
+		fmt.Fprintf(w, "预设 %s (%s, %s):\n", p.Name, p.Job, p.Env)
+		for _, key := range sortedKeys(p.Params) {
+			fmt.Fprintf(w, "%s: %s\n", key, p.Params[key])
+		}
+	}))
+}
+
+// loadPreset 读取 --preset 指定的预设并按任务当前的参数定义校验，
+// 返回的值与 -a 一样还没有解析 @path 和 env:VAR 引用
+func loadPreset(env jj.Env, job string, params []jj.ParameterDefinitions) (map[string]string, error) {
+	if presetName == "" {
+		return nil, nil
+	}
+	p, err := jj.DefaultPresetStore.Get(env.Name, job, presetName)
+	if err != nil {
+		return nil, err
+	}
+	resolved := p
+	resolved.Params = map[string]string{}
+	for name, val := range p.Params {
+		resolved.Params[name] = val
+		for _, pd := range params {
+			if pd.Name == name {
+				if resolved.Params[name], err = resolveParam(pd, val); err != nil {
+					return nil, fmt.Errorf("preset '%s': %w", p.Name, err)
+				}
+			}
+		}
+	}
+	if err := jj.CheckPreset(resolved, params); err != nil {
+		return nil, err
+	}
+	return p.Params, nil
+}
+
+// savePreset 保存本次运行的参数，密码参数不会被保存。raw 中的值按指定时的
+// 原样保存，@path 和 env:VAR 在使用预设时才解析，其它的值转义后保存
+func savePreset(env jj.Env, job, name string, params []jj.ParameterDefinitions, data, raw map[string]string) error {
+	values := map[string]string{}
+	for _, pd := range params {
+		if pd.Kind() == jj.ParamPassword {
+			continue
+		}
+		if val, ok := raw[pd.Name]; ok {
+			values[pd.Name] = val
+		} else if val, ok := data[pd.Name]; ok {
+			values[pd.Name] = escapeValue(val)
+		}
+	}
+	err := jj.DefaultPresetStore.Save(jj.Preset{Env: env.Name, Job: job, Name: name, Params: values})
+	if err == nil {
+		fmt.Printf("已保存预设 %s\n", name)
+	}
+	return err
+}
+
+func formatParams(params map[string]string) string {
+	pairs := []string{}
+	for _, key := range sortedKeys(params) {
+		pairs = append(pairs, key+"="+params[key])
+	}
+	return strings.Join(pairs, ",")
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj"
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj/jjtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunJobPreset(t *testing.T) {
+	srv := jjtest.New()
+	defer srv.Close()
+	branch := jj.ParameterDefinitions{Name: "BRANCH", Type: "StringParameterDefinition"}
+	branch.DefaultParameterValue.Value = "master"
+	target := jj.ParameterDefinitions{Name: "TARGET", Type: "ChoiceParameterDefinition", Choices: []string{"uat", "prod"}}
+	token := jj.ParameterDefinitions{Name: "TOKEN", Type: "PasswordParameterDefinition"}
+	notes := jj.ParameterDefinitions{Name: "NOTES", Type: "TextParameterDefinition"}
+	srv.AddJob("app", branch, target, token, notes)
+	env := useServer(t, srv)
+
+	nonInteractive = true
+	defer func() {
+		nonInteractive = false
+		inputArgs = arguments{}
+		presetName, savePresetName = "", ""
+	}()
+
+	inputArgs = arguments{args: []string{"BRANCH=hotfix", "TARGET=prod", "TOKEN=secret"}}
+	savePresetName = "hotfix"
+	assert.Equal(t, exitSuccess, exitCode(runJob("app")))
+	p, err := jj.DefaultPresetStore.Get(env.Name, "app", "hotfix")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"BRANCH": "hotfix", "TARGET": "prod", "NOTES": ""}, p.Params)
+
+	// -a overrides values of the preset
+	inputArgs = arguments{args: []string{"BRANCH=release"}}
+	savePresetName, presetName = "", "hotfix"
+	assert.Equal(t, exitSuccess, exitCode(runJob("app")))
+	builds := srv.Builds("app")
+	assert.Len(t, builds, 2)
+	assert.Equal(t, "release", builds[1].Params.Get("BRANCH"))
+	assert.Equal(t, "prod", builds[1].Params.Get("TARGET"))
+
+	// references are saved as they are and resolved when the preset is used
+	dir, err := ioutil.TempDir("", "jj")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	notesFile := filepath.Join(dir, "notes.txt")
+	assert.NoError(t, ioutil.WriteFile(notesFile, []byte("big file"), 0644))
+	os.Setenv("JJ_TEST_BRANCH", "feature")
+	defer os.Unsetenv("JJ_TEST_BRANCH")
+	inputArgs = arguments{args: []string{"BRANCH=env:JJ_TEST_BRANCH", "TARGET=uat", "NOTES=@" + notesFile}}
+	savePresetName, presetName = "refs", ""
+	assert.Equal(t, exitSuccess, exitCode(runJob("app")))
+	refs, err := jj.DefaultPresetStore.Get(env.Name, "app", "refs")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"BRANCH": "env:JJ_TEST_BRANCH", "NOTES": "@" + notesFile, "TARGET": "uat"}, refs.Params)
+
+	os.Setenv("JJ_TEST_BRANCH", "hotfix-2")
+	inputArgs = arguments{}
+	savePresetName, presetName = "", "refs"
+	assert.Equal(t, exitSuccess, exitCode(runJob("app")))
+	builds = srv.Builds("app")
+	assert.Equal(t, "hotfix-2", builds[3].Params.Get("BRANCH"))
+	assert.Equal(t, "big file", builds[3].Params.Get("NOTES"))
+
+	// the preset is checked against the current definitions of the job
+	p.Params["TARGET"] = "dev"
+	assert.NoError(t, jj.DefaultPresetStore.Save(p))
+	presetName = "hotfix"
+	_, err = loadPreset(env, "app", []jj.ParameterDefinitions{branch, target, notes})
+	assert.Error(t, err)
+}
@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	var watch bool
+	var interval time.Duration
+	queueCmd := &cobra.Command{
+		Use:     "queue",
+		Aliases: []string{"q"},
+		Short:   "查看 Jenkins 的构建队列",
+		Long:    `列出队列中等待的构建，包括任务、等待原因、等待时间和参数。`,
+		Run: func(cmd *cobra.Command, args []string) {
+			env := mustInit(ENV)
+			if !watch {
+				check(showQueue(env))
+				return
+			}
+			// 定时刷新，Ctrl+C 退出
+			for {
+				fmt.Print("\033[H\033[2J")
+				check(showQueue(env))
+				time.Sleep(interval)
+			}
+		},
+		Args:    cobra.NoArgs,
+		PreRunE: preRunE,
+	}
+	queueCmd.Flags().StringVarP(&ENV, "name", "n", "", "current Jenkins name")
+	queueCmd.Flags().BoolVarP(&watch, "watch", "w", false, "refresh the queue until Ctrl+C")
+	queueCmd.Flags().DurationVar(&interval, "interval", 2*time.Second, "refresh interval of --watch")
+
+	var job string
+	var allMine bool
+	cancelCmd := &cobra.Command{
+		Use:   "cancel [ID...]",
+		Short: "取消队列中的构建",
+		Long: `取消队列中指定 ID 的构建，或者用 --job 取消某个任务的全部排队构建，
+用 --all-mine 取消当前用户触发的全部排队构建。`,
+		Run: func(cmd *cobra.Command, args []string) {
+			env := mustInit(ENV)
+			switch {
+			case job != "":
+				name, err := selectJob(env, job)
+				check(err)
+				if cancelJobQueue(env, name) == 0 {
+					fmt.Printf("任务 %s 没有排队中的构建\n", name)
+				}
+			case allMine:
+				if cancelMyQueue(env) == 0 {
+					fmt.Println("没有你触发的排队中的构建")
+				}
+			default:
+				for _, arg := range args {
+					id, _ := strconv.Atoi(arg)
+					check(jj.CancelQueue(env, id))
+					fmt.Printf("取消排队中的构建, queue id: %d\n", id)
+				}
+			}
+		},
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			selectors := 0
+			if len(args) > 0 {
+				selectors++
+			}
+			if job != "" {
+				selectors++
+			}
+			if allMine {
+				selectors++
+			}
+			if selectors != 1 {
+				return errors.New("specify queue ids, --job or --all-mine")
+			}
+			for _, arg := range args {
+				if _, err := strconv.Atoi(arg); err != nil {
+					return fmt.Errorf("无效的 queue id: %s", arg)
+				}
+			}
+			return preRunE(cmd, args)
+		},
+	}
+	cancelCmd.Flags().StringVarP(&ENV, "name", "n", "", "current Jenkins name")
+	cancelCmd.Flags().StringVar(&job, "job", "", "cancel all queued builds of the job")
+	cancelCmd.Flags().BoolVar(&allMine, "all-mine", false, "cancel all queued builds started by the current user")
+	queueCmd.AddCommand(cancelCmd)
+	rootCmd.AddCommand(queueCmd)
+}
+
+func showQueue(env jj.Env) error {
+	queues, err := jj.GetQueues(env)
+	if err != nil {
+		return err
+	}
+	items := []jj.QueueSummary{}
+	for _, item := range queues.Items {
+		items = append(items, item.Summary())
+	}
+	return printOutput(items, func(out io.Writer, wide bool) {
+		if len(items) == 0 {
+			fmt.Fprintln(out, "队列中没有等待的构建")
+			return
+		}
+		w := new(tabwriter.Writer)
+		w.Init(out, 0, 8, 1, '\t', 0)
+		if !noheader {
+			if wide {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", "ID", "Job", "Waiting", "Why", "User", "Params")
+			} else {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", "ID", "Job", "Waiting", "Why")
+			}
+		}
+		for _, item := range items {
+			waiting := time.Since(time.Unix(0, item.InQueueSince*int64(time.Millisecond))).Round(time.Second)
+			why := item.Why
+			if item.Stuck {
+				why = "[stuck] " + why
+			}
+			if wide {
+				params := []string{}
+				for _, p := range item.Parameters {
+					params = append(params, p.Name+"="+p.Value)
+				}
+				fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", item.ID, item.Job, waiting, why, item.UserID, strings.Join(params, ","))
+			} else {
+				fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", item.ID, item.Job, waiting, why)
+			}
+		}
+		fmt.Fprintln(w)
+		w.Flush()
+	})
+}
+
+// cancelMyQueue 取消当前用户触发的所有排队构建
+func cancelMyQueue(env jj.Env) int {
+	if env.Login == "" {
+		check(fmt.Errorf("Jenkins '%s' has no login, --all-mine needs it to find your builds", env.Name))
+	}
+	queues, err := jj.GetQueues(env)
+	check(err)
+	canceled := 0
+	for _, item := range queues.Items {
+		if !strings.EqualFold(item.UserID(), env.Login) {
+			continue
+		}
+		fmt.Printf("取消排队中的构建 %s, queue id: %d\n", item.Job(), item.ID)
+		check(jj.CancelQueue(env, item.ID))
+		canceled++
+	}
+	return canceled
+}
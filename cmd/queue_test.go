@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj"
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj/jjtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCancelMyQueue(t *testing.T) {
+	srv := jjtest.New()
+	defer srv.Close()
+	srv.AddJob("app").Script = jjtest.Script{QueuePolls: 100}
+	alice := srv.Env()
+	alice.Name, alice.Type, alice.Login, alice.Secret = "alice", "a", "alice", "token"
+	bob := alice
+	bob.Name, bob.Login = "bob", "bob"
+
+	for _, env := range []jj.Env{alice, bob, alice} {
+		err, _ := jj.Build(env, "app", "BRANCH=master")
+		assert.NoError(t, err)
+	}
+	queues, err := jj.GetQueues(alice)
+	assert.NoError(t, err)
+	assert.Len(t, queues.Items, 3)
+	summary := queues.Items[1].Summary()
+	assert.Equal(t, "app", summary.Job)
+	assert.Equal(t, "bob", summary.UserID)
+	assert.Equal(t, []jj.Parameter{{Name: "BRANCH", Value: "master"}}, summary.Parameters)
+
+	assert.Equal(t, 2, cancelMyQueue(alice))
+	queues, err = jj.GetQueues(alice)
+	assert.NoError(t, err)
+	assert.Len(t, queues.Items, 1)
+	assert.Equal(t, "bob", queues.Items[0].UserID())
+}
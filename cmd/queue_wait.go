@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj"
+)
+
+// queueTimeout 是构建在队列中等待的最长时间，0 表示一直等待
+var queueTimeout time.Duration
+
+// 排队时刷新执行器使用情况的间隔
+const labelLoadInterval = 10 * time.Second
+
+// queueStatus 显示排队中的构建的等待原因、等待时间以及任务所在标签的执行器
+type queueStatus struct {
+	env        jj.Env
+	label      string
+	labelKnown bool
+	load       string
+	loadAt     time.Time
+	why        string
+	shown      bool
+}
+
+func (s *queueStatus) update(qi jj.QueueInfo, waited time.Duration) {
+	why, _ := qi.Why.(string)
+	if why == "" {
+		why = "waiting for next available executor"
+	}
+	if time.Since(s.loadAt) > labelLoadInterval {
+		s.loadAt = time.Now()
+		s.load = s.executors(qi)
+	}
+	line := fmt.Sprintf("⏳ %s %s%s", waited.Round(time.Second), why, s.load)
+	if interactive() {
+		// 在同一行刷新
+		fmt.Printf("\r\033[K%s", line)
+		s.shown = true
+	} else if why != s.why {
+		fmt.Println(line)
+	}
+	s.why = why
+}
+
+func (s *queueStatus) executors(qi jj.QueueInfo) string {
+	if !s.labelKnown {
+		s.labelKnown = true
+		job := string(jj.ParseJobURL(qi.Task.URL))
+		ji := jj.GetClient(s.env).CachedJobInfo(job)
+		if ji == nil {
+			_, ji = jj.GetJobInfo(s.env, job)
+		}
+		if ji != nil {
+			s.label = ji.LabelExpression
+		}
+	}
+	load, err := jj.GetLabelLoad(s.env, s.label)
+	if err != nil {
+		return ""
+	}
+	label := s.label
+	if label == "" {
+		label = "all nodes"
+	}
+	return fmt.Sprintf(" [%s: %d idle, %d busy]", label, load.Idle, load.Busy)
+}
+
+// done 结束刷新的状态行
+func (s *queueStatus) done() {
+	if s.shown {
+		fmt.Println()
+		s.shown = false
+	}
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj"
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj/jjtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueueTimeout(t *testing.T) {
+	srv := jjtest.New()
+	defer srv.Close()
+	job := srv.AddJob("linux-build")
+	job.Script = jjtest.Script{QueuePolls: 1000}
+	job.Label = "linux"
+	srv.Executors["linux"] = jjtest.Executors{Busy: 2}
+	env := useServer(t, srv)
+
+	nonInteractive = true
+	queueTimeout = 50 * time.Millisecond
+	defer func() {
+		nonInteractive = false
+		queueTimeout = 0
+	}()
+
+	err := runJob("linux-build")
+	assert.True(t, errors.Is(err, errTimeout))
+	assert.Equal(t, exitTimeout, exitCode(err))
+	assert.Equal(t, 1, srv.Requests["/label/linux/api/json"])
+	queues, err := jj.GetQueues(env)
+	assert.NoError(t, err)
+	assert.Len(t, queues.Items, 0)
+}
+
+func TestQueueTimeoutUnavailable(t *testing.T) {
+	srv := jjtest.New()
+	defer srv.Close()
+	srv.AddJob("linux-build").Script = jjtest.Script{QueuePolls: 1000}
+	srv.QueueStatus = 503
+	env := useServer(t, srv)
+
+	nonInteractive = true
+	queueTimeout = 50 * time.Millisecond
+	defer func() {
+		nonInteractive = false
+		queueTimeout = 0
+	}()
+
+	done := make(chan error)
+	go func() { done <- runJob("linux-build") }()
+	select {
+	case err := <-done:
+		assert.Equal(t, exitTimeout, exitCode(err))
+	case <-time.After(10 * time.Second):
+		t.Fatal("--queue-timeout is ignored while Jenkins is unavailable")
+	}
+	queues, err := jj.GetQueues(env)
+	assert.NoError(t, err)
+	assert.Len(t, queues.Items, 0)
+}
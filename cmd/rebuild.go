@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj"
+	"github.com/spf13/cobra"
+)
+
+var likeLast bool
+
+// rebuildFrom 是 rebuild 命令所用的构建号或 lastBuild
+var rebuildFrom string
+
+func init() {
+	rebuildCmd := &cobra.Command{
+		Use:   "rebuild JOB [BUILD]",
+		Short: "使用以前构建的参数重新运行任务",
+		Long: `使用指定构建（默认为最后一次构建）的参数重新运行任务，并像 run 一样跟踪它和它的下游任务。
+可以用 -a key=val 覆盖部分参数，密码和文件参数不会被复用。
+退出码与 run 命令相同。`,
+		Run: func(cmd *cobra.Command, args []string) {
+			env := mustInit(ENV)
+			job, err := selectJob(env, args[0])
+			check(err)
+			rebuildFrom = "lastBuild"
+			if len(args) > 1 {
+				rebuildFrom = args[1]
+			}
+			exit(runJob(job))
+		},
+		Args: cobra.RangeArgs(1, 2),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 1 && args[1] != "lastBuild" {
+				if _, err := strconv.Atoi(args[1]); err != nil {
+					return fmt.Errorf("无效的构建号: %s", args[1])
+				}
+			}
+			return runPreRunE(cmd, args)
+		},
+	}
+	rebuildCmd.Flags().StringArrayVarP(&inputArgs.args, "arg", "a", []string{}, "override arguments of the build. Usage: -a key=val")
+	rebuildCmd.Flags().StringVarP(&ENV, "name", "n", "", "current Jenkins name")
+	rebuildCmd.Flags().DurationVar(&queueTimeout, "queue-timeout", 0, "cancel the build if it waits in the queue longer, e.g. 10m")
+	rebuildCmd.Flags().DurationVar(&watchTimeout, "timeout", watchTimeout, "stop watching a build running longer, 0 watches until it ends")
+	rebuildCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "显示详细的构建输出")
+	rebuildCmd.SetUsageTemplate(usageTamplate)
+	rootCmd.AddCommand(rebuildCmd)
+}
+
+// previousParams 返回 rebuild 或 --like-last 所用构建的参数，都未指定时返回 nil
+func previousParams(env jj.Env, job string) (map[string]string, error) {
+	build := rebuildFrom
+	if build == "" {
+		if !likeLast {
+			return nil, nil
+		}
+		build = "lastBuild"
+	}
+	var bi *jj.BuildInfo
+	var err error
+	if build == "lastBuild" {
+		bi, err = jj.GetLastBuildInfo(env, job)
+	} else {
+		number, _ := strconv.Atoi(build)
+		bi, err = jj.GetBuildInfo(env, job, number)
+	}
+	if errors.Is(err, jj.ErrNotFound) {
+		if rebuildFrom == "" {
+			fmt.Printf("任务 %s 还没有构建, 使用参数的默认值\n", job)
+			return nil, nil
+		}
+		return nil, fmt.Errorf("任务 %s 的构建 %s 不存在", job, build)
+	}
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]string{}
+	for _, p := range bi.Parameters() {
+		values[p.Name] = p.Value
+	}
+	fmt.Printf("使用构建 #%d 的参数\n", bi.Number)
+	return values, nil
+}
+
+// withDefaults 用以前构建的参数值替换参数的默认值，
+// Jenkins 不会返回密码和文件参数的值，这两种参数保留原来的默认值
+func withDefaults(params []jj.ParameterDefinitions, values map[string]string) []jj.ParameterDefinitions {
+	if values == nil {
+		return params
+	}
+	rsp := make([]jj.ParameterDefinitions, 0, len(params))
+	for _, pd := range params {
+		val, ok := values[pd.Name]
+		if ok && pd.Kind() != jj.ParamPassword && pd.Kind() != jj.ParamFile {
+			pd.DefaultParameterValue.Value = val
+		}
+		rsp = append(rsp, pd)
+	}
+	return rsp
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj"
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj/jjtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRebuild(t *testing.T) {
+	srv := jjtest.New()
+	defer srv.Close()
+	branch := jj.ParameterDefinitions{Name: "BRANCH", Type: "StringParameterDefinition"}
+	branch.DefaultParameterValue.Value = "master"
+	target := jj.ParameterDefinitions{Name: "TARGET", Type: "ChoiceParameterDefinition", Choices: []string{"uat", "prod"}}
+	target.DefaultParameterValue.Value = "uat"
+	srv.AddJob("app", branch, target)
+	env := useServer(t, srv)
+
+	nonInteractive = true
+	defer func() {
+		nonInteractive = false
+		inputArgs = arguments{}
+		rebuildFrom = ""
+		likeLast = false
+	}()
+
+	inputArgs = arguments{args: []string{"BRANCH=develop", "TARGET=prod"}}
+	assert.Equal(t, exitSuccess, exitCode(runJob("app")))
+	inputArgs = arguments{args: []string{"BRANCH=release"}}
+	assert.Equal(t, exitSuccess, exitCode(runJob("app")))
+
+	// the first build with TARGET overridden
+	inputArgs = arguments{args: []string{"TARGET=uat"}}
+	rebuildFrom = "1"
+	assert.Equal(t, exitSuccess, exitCode(runJob("app")))
+	builds := srv.Builds("app")
+	assert.Len(t, builds, 3)
+	assert.Equal(t, "develop", builds[2].Params.Get("BRANCH"))
+	assert.Equal(t, "uat", builds[2].Params.Get("TARGET"))
+
+	rebuildFrom = "7"
+	_, err := previousParams(env, "app")
+	assert.Error(t, err)
+
+	rebuildFrom = ""
+	likeLast = true
+	last, err := previousParams(env, "app")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"BRANCH": "develop", "TARGET": "uat"}, last)
+}
+
+func TestWithDefaults(t *testing.T) {
+	params := []jj.ParameterDefinitions{
+		{Name: "BRANCH", Type: "StringParameterDefinition"},
+		{Name: "TOKEN", Type: "PasswordParameterDefinition"},
+		{Name: "PACKAGE", Type: "FileParameterDefinition"},
+	}
+	params[1].DefaultParameterValue.Value = "default"
+	rsp := withDefaults(params, map[string]string{"BRANCH": "develop", "TOKEN": "", "PACKAGE": "app.tar", "REMOVED": "x"})
+	assert.Equal(t, "develop", rsp[0].DefaultParameterValue.Value)
+	assert.Equal(t, "default", rsp[1].DefaultParameterValue.Value)
+	assert.Equal(t, "", rsp[2].DefaultParameterValue.Value)
+	assert.Equal(t, "", params[0].DefaultParameterValue.Value)
+}
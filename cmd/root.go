@@ -119,6 +119,15 @@ if this does not work for some reason, try calling "jj completion check" command
 }
 var inputArgs arguments
 
+func init() {
+	rootCmd.PersistentFlags().BoolVarP(&nonInteractive, "yes", "y", false, "never ask anything, use default values of parameters and print plain logs (on when stdin is not a terminal)")
+	rootCmd.PersistentFlags().BoolVar(&nonInteractive, "non-interactive", false, "the same as --yes")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "", outputUsage)
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		return validateOutput()
+	}
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"html"
-	"net/url"
 	"os"
 	"os/exec"
 	"os/signal"
@@ -58,9 +57,11 @@ type st struct {
 
 var curSt st
 var barMutex sync.Mutex
-var closeCh chan struct{}
 var stdinListener *jjStdin
 
+// watchTimeout 是跟踪一次构建的最长时间，等待 input 的时间不计入，0 表示不限制
+var watchTimeout = 10 * time.Minute
+
 var verbose bool
 
 func init() {
@@ -68,67 +69,27 @@ func init() {
 		Use:     "run JOB",
 		Aliases: []string{"r"},
 		Short:   "Run the specified jenkins job",
+		Long: `Run the specified jenkins job and watch it and its downstream jobs.
+
+Exit codes:
+  0  all builds are SUCCESS
+  1  jj failed, e.g. bad arguments, config or network
+  2  a build is FAILURE
+  3  a build is UNSTABLE
+  4  a build is ABORTED or NOT_BUILT
+  5  timeout while watching the build
+  6  the queue item was cancelled
+  7  unknown result of a build`,
 		Run: func(cmd *cobra.Command, args []string) {
 			if len(args) == 0 {
 				fmt.Println("请指定要运行的 Jenkins 任务名称")
 				return
 			}
 
-			// 获取匹配的任务列表
-			env := jj.Init(ENV)
-			jobs := findMatchingJobs(env, args[0])
-
-			// 若首次匹配不到，强制刷新 Jenkins 视图缓存后重试
-			if len(jobs) == 0 {
-				jj.RefreshBundle(env)
-				jobs = findMatchingJobs(env, args[0])
-			}
-
-			if len(jobs) == 0 {
-				fmt.Printf("未找到匹配的任务: %s\n", args[0])
-				return
-			}
-
-			// 如果完全匹配某个任务名称，直接运行该任务
-			for _, job := range jobs {
-				if job == args[0] {
-					runJob(job)
-					return
-				}
-			}
-
-			// 如果只有一个匹配项，直接运行
-			if len(jobs) == 1 {
-				runJob(jobs[0])
-				return
-			}
-
-			// 多个匹配项，让用户选择
-			fmt.Printf("\n找到 %d 个匹配的任务:\n", len(jobs))
-			for i, job := range jobs {
-				fmt.Printf("%d. %s\n", i+1, job)
-			}
-
-			rl, err := readline.New("请选择要运行的任务编号: ")
-			if err != nil {
-				fmt.Printf("读取输入失败: %v\n", err)
-				return
-			}
-			defer rl.Close()
-
-			line, err := rl.Readline()
-			if err != nil {
-				fmt.Printf("读取输入失败: %v\n", err)
-				return
-			}
-
-			index, err := strconv.Atoi(strings.TrimSpace(line))
-			if err != nil || index < 1 || index > len(jobs) {
-				fmt.Println("无效的选择")
-				return
-			}
-
-			runJob(jobs[index-1])
+			env := mustInit(ENV)
+			job, err := selectJob(env, args[0])
+			check(err)
+			exit(runJob(job))
 		},
 		Args:         cobra.MaximumNArgs(1),
 		PreRunE:      runPreRunE,
@@ -137,6 +98,13 @@ func init() {
 	inputArgs = arguments{args: make([]string, 0, 20)}
 	runCmd.Flags().StringArrayVarP(&inputArgs.args, "arg", "a", []string{}, "input arguments of a job. Usage: -a key=val")
 	runCmd.Flags().StringVarP(&ENV, "name", "n", "", "current Jenkins name")
+	runCmd.Flags().StringVar(&paramsFile, "params-file", "", "read parameters from a yaml, json or .env file, - reads stdin")
+	runCmd.Flags().StringVar(&paramsInput, "params", "", "read parameters from stdin: --params -")
+	runCmd.Flags().DurationVar(&queueTimeout, "queue-timeout", 0, "cancel the build if it waits in the queue longer, e.g. 10m")
+	runCmd.Flags().DurationVar(&watchTimeout, "timeout", watchTimeout, "stop watching a build running longer, 0 watches until it ends")
+	runCmd.Flags().BoolVar(&likeLast, "like-last", false, "use parameters of the last build as defaults")
+	runCmd.Flags().StringVar(&presetName, "preset", "", "use parameters of the saved preset, -a overrides them")
+	runCmd.Flags().StringVar(&savePresetName, "save-preset", "", "save parameters of the run as a preset of the job")
 	// 添加 verbose 参数
 	runCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "显示详细的构建输出")
 	runCmd.SetUsageTemplate(usageTamplate)
@@ -148,87 +116,86 @@ func runPreRunE(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	if paramsInput != "" {
+		if paramsInput != "-" || paramsFile != "" {
+			return errors.New("--params only accepts '-' to read stdin, use --params-file for files")
+		}
+		paramsFile = "-"
+	}
 	return preRunE(cmd, args)
 }
 
-func askParams(params []jj.ParameterDefinitions) map[string]string {
+func askParams(env jj.Env, job string, params []jj.ParameterDefinitions) map[string]string {
 	data := map[string]string{}
 	for _, pd := range params {
-		cline := ""
-		defVal := pd.DefaultParameterValue.Value
-		curChoices := pd.Choices
-		for {
-			rl, err := NewReadLine(chalk.Underline.TextStyle(pd.Name)+": ", pd.Choices)
-			defer rl.Close()
-			if err != nil {
-				os.Exit(1)
+		data[pd.Name] = askParam(env, job, pd)
+	}
+	return data
+}
+
+// askChoice 让用户从 choices 中选择一个值，输入不完整时给出匹配的候选项
+func askChoice(name string, choices []string, defVal string) string {
+	cline := ""
+	curChoices := choices
+	for {
+		rl, err := NewReadLine(chalk.Underline.TextStyle(name)+": ", choices)
+		defer rl.Close()
+		if err != nil {
+			os.Exit(1)
+		}
+		line, err := rl.ReadlineWithDefault(defVal)
+		line = strings.TrimSpace(line)
+		if err != nil { // io.EOF
+			os.Exit(1)
+		}
+		for _, val := range choices {
+			if line == val {
+				cline = val
+				break
 			}
-			if pd.Type == "ChoiceParameterDefinition" {
-				defVal = ""
+		}
+		if cline == "" {
+			curChoices = findBestChoices(line, choices)
+			if len(curChoices) == 0 {
+				curChoices = choices
+			} else if len(curChoices) == 1 {
+				defVal = curChoices[0]
+			} else {
+				defVal = line
 			}
-			line, err := rl.ReadlineWithDefault(defVal)
-			line = strings.TrimSpace(line)
-			if err != nil { // io.EOF
-				os.Exit(1)
+			for _, val := range curChoices {
+				fmt.Printf("%s\t", val)
 			}
-			if pd.Type == "ChoiceParameterDefinition" {
-
-				for _, val := range pd.Choices {
-					if line == val {
-						cline = val
-						break
-					}
-				}
-				if cline == "" {
-					curChoices = findBestChoices(line, pd.Choices)
-					if len(curChoices) == 0 {
-						curChoices = pd.Choices
-					} else if len(curChoices) == 1 {
-						defVal = curChoices[0]
-					} else {
-						defVal = line
-					}
-					for _, val := range curChoices {
-						fmt.Printf("%s\t", val)
-					}
-					if len(curChoices) > 0 {
-						fmt.Println()
-					}
-
-					continue
-				}
-			} else {
-				cline = line
+			if len(curChoices) > 0 {
+				fmt.Println()
 			}
-			break
-		}
-
-		data[pd.Name] = cline
 
+			continue
+		}
+		return cline
 	}
-	return data
 }
 
-func runJob(name string) {
-	env := jj.Init(ENV)
+// runJob 运行任务并跟踪下游任务，返回的错误决定 jj 的退出码
+func runJob(name string) error {
+	env := mustInit(ENV)
 	time.Sleep(time.Millisecond * 200)
 	fmt.Printf("Job will be started in the %s environment\n", chalk.Underline.TextStyle(string(env.Name)))
 	time.Sleep(time.Millisecond * 200)
-	if env.Url[len(env.Url)-1:] != "/" {
-		env.Url = env.Url + "/"
-	}
-	fmt.Println("Link: ", env.Url+"job/"+name)
+	fmt.Println("Link: ", strings.TrimSuffix(env.Url, "/")+"/"+jj.JobPath(name).URL())
 	time.Sleep(time.Millisecond * 200)
 
-	bar.InitTerminal()
+	if interactive() {
+		bar.InitTerminal()
+	}
 	data := map[string]string{}
 	err, jobInfo := jj.GetJobInfo(env, name)
-	if err == jj.ErrNoJob {
+	if errors.Is(err, jj.ErrNotFound) {
 		err = fmt.Errorf("job '%s' does not exist", name)
 	}
 	check(err)
 	params := jobInfo.GetParameterDefinitions()
-	if len(params) == 0 {
+	if len(params) == 0 && interactive() {
 		rl, err := readline.New("Press any key to continue: ")
 		defer rl.Close()
 		_, err = rl.Readline()
@@ -236,90 +203,155 @@ func runJob(name string) {
 			os.Exit(1)
 		}
 	}
-	if len(inputArgs.args) > 0 {
-		for _, pd := range params {
-			val, err := inputArgs.get(pd.Name)
-			if err != nil {
-				data[pd.Name] = pd.DefaultParameterValue.Value
-			} else {
-				data[pd.Name] = val
-			}
-		}
+	last, err := previousParams(env, name)
+	check(err)
+	params = withDefaults(params, last)
+	preset, err := loadPreset(env, name, params)
+	check(err)
+	var fileParams map[string]string
+	if paramsFile != "" {
+		fileParams, err = readParams(paramsFile, os.Stdin)
+		check(err)
+	}
+	// 非交互模式下未指定的参数使用默认值
+	var raw map[string]string
+	if len(inputArgs.args) > 0 || preset != nil || fileParams != nil || rebuildFrom != "" || !interactive() {
+		raw, err = rawParams(params, preset, fileParams)
+		check(err)
+		data, err = mergeParams(params, preset, fileParams)
+		check(err)
 	} else {
-		data = askParams(params)
+		data = askParams(env, name, params)
 	}
 
-	urlquery := url.Values{}
-	for key, val := range data {
-		urlquery.Add(key, val)
-	}
-	err, queueId := jj.Build(env, name, urlquery.Encode())
+	queueId1, err := submitBuild(env, name, params, data)
 	check(err)
+	if savePresetName != "" {
+		check(savePreset(env, name, savePresetName, params, data, raw))
+	}
 
-	keyCh := make(chan string)
-	stdinListener = NewStdin()
-	go listenKeys(keyCh)
+	var keyCh chan string
+	if interactive() {
+		keyCh = make(chan string)
+		stdinListener = NewStdin()
+		go listenKeys(keyCh)
+	}
 	go listenInterrupt(env)
-	queueId1, _ := strconv.Atoi(queueId)
 	curSt.queue = queueId1
 	curSt.name = name
-	number := waitForExecutor(env, queueId1)
+	number, err := waitForExecutor(env, queueId1)
+	if err != nil {
+		return err
+	}
 	curSt.id = number
 	err = watchTheJob(env, name, number, keyCh)
 	if err != nil {
-		return
+		printTestSummary(env, err)
+		return err
 	}
 	curSt = st{}
+	if err := watchDownstream(env, name, number, jobInfo, keyCh); err != nil {
+		printTestSummary(env, err)
+		return err
+	}
+	fmt.Println(chalk.Green.Color("done"))
+	return nil
+}
+
+// watchDownstream 依次跟踪下游任务由该构建触发的构建
+func watchDownstream(env jj.Env, name string, number int, jobInfo *jj.JobInfo, keyCh chan string) error {
 	for _, jChild := range jobInfo.DownstreamProjects {
-		err = watchNext(env, name, jChild.Name, number, keyCh)
+		childName := string(jj.ParseJobURL(jChild.URL))
+		if childName == "" {
+			childName = jChild.Name
+		}
+		// 下游任务的结果同样计入退出码
+		err := watchNext(env, name, childName, number, keyCh)
 		if err != nil {
-			return
+			return err
 		}
 		curSt = st{}
 	}
-	fmt.Println(chalk.Green.Color("done"))
-	return
+	return nil
 }
 
-func waitForExecutor(env jj.Env, queueId int) int {
-	informed := false
+func waitForExecutor(env jj.Env, queueId int) (int, error) {
+	poll := env.Polling.Backoff()
+	status := &queueStatus{env: env}
+	defer status.done()
+	start := time.Now()
 	for {
 		err, queueInfo := jj.GetQueueInfo(env, queueId)
-		check(err)
-		if !queueInfo.Blocked && queueInfo.Executable.URL != "" {
-			return queueInfo.Executable.Number
-		} else {
-			if !informed {
-				//clearer := strings.Repeat(" ", int(110)-1)
-				fmt.Println("waiting for next available executor..  ")
-				informed = true
+		waited := time.Since(start)
+		if jj.IsTemporary(err) {
+			// Jenkins 一直不可用时也按 --queue-timeout 结束
+			if queueTimeout > 0 && waited > queueTimeout {
+				return 0, cancelQueued(env, queueId, status)
 			}
-			time.Sleep(100 * time.Millisecond)
+			poll.Sleep()
+			continue
+		}
+		if err != nil {
+			return 0, err
 		}
+		if queueInfo.Cancelled {
+			return 0, errQueueCancelled
+		}
+		if !queueInfo.Blocked && queueInfo.Executable.URL != "" {
+			return queueInfo.Executable.Number, nil
+		}
+		if queueTimeout > 0 && waited > queueTimeout {
+			return 0, cancelQueued(env, queueId, status)
+		}
+		status.update(queueInfo, waited)
+		// 排队时间越长，轮询间隔越大
+		poll.Sleep()
 	}
 }
 
-func barHandler(jobUrl string, keyCh chan string, chMsg chan string, finishCh chan struct {
+// cancelQueued 在排队超过 --queue-timeout 时取消排队中的构建
+func cancelQueued(env jj.Env, queueId int, status *queueStatus) error {
+	status.done()
+	fmt.Printf("排队超过 %s, 取消排队中的构建, queue id: %d\n", queueTimeout, queueId)
+	if err := jj.CancelQueue(env, queueId); err != nil {
+		return err
+	}
+	return fmt.Errorf("queue item %d has waited longer than %s: %w", queueId, queueTimeout, errTimeout)
+}
+
+// barFormat 返回进度条的格式，stages 是 Pipeline 正在运行的阶段
+func barFormat(stages string) string {
+	if stages != "" {
+		stages = " [" + stages + "]"
+	}
+	return fmt.Sprintf(
+		"%srunning%s...%s :percent :bar %s:eta%s",
+		chalk.White,
+		stages,
+		chalk.Reset,
+		chalk.Green,
+		chalk.Reset)
+}
+
+func barHandler(jobUrl string, keyCh chan string, chMsg chan string, stageCh chan string, finishCh chan struct {
 	err    error
 	result string
-}, wg *sync.WaitGroup) {
+}, closeCh chan struct{}, wg *sync.WaitGroup) {
 	defer wg.Done()
 	barMutex.Lock()
 	fmt.Print("\033[F")
 	br := bar.NewWithOpts(
 		bar.WithDimensions(100, 20),
 		bar.WithLines(1),
-		bar.WithFormat(
-			fmt.Sprintf(
-				"%srunning...%s :percent :bar %s:eta%s",
-				chalk.White,
-				chalk.Reset,
-				chalk.Green,
-				chalk.Reset)))
+		bar.WithFormat(barFormat("")))
 	br.Tick()
 	barMutex.Unlock()
 	for {
 		select {
+		case stages := <-stageCh:
+			barMutex.Lock()
+			br.SetFormat(barFormat(stages))
+			barMutex.Unlock()
 		case stdin, _ := <-keyCh:
 			if []byte(stdin)[0] == 10 {
 				barMutex.Lock()
@@ -365,19 +397,51 @@ func barHandler(jobUrl string, keyCh chan string, chMsg chan string, finishCh ch
 	}
 }
 
+// plainHandler 逐行输出日志，在非交互模式下代替进度条
+func plainHandler(jobUrl string, chMsg chan string, finishCh chan struct {
+	err    error
+	result string
+}, closeCh chan struct{}, wg *sync.WaitGroup) {
+	defer wg.Done()
+	for {
+		select {
+		case msg := <-chMsg:
+			if msg != "" {
+				fmt.Println(msg)
+			}
+		case info := <-finishCh:
+			fmt.Printf("%s: %s\n", jobUrl, info.result)
+			if info.err != nil {
+				fmt.Println("failed")
+			}
+			return
+		case <-closeCh:
+			return
+		}
+	}
+}
+
 // 在watchTheJob函数中添加部署后检查
 func watchTheJob(env jj.Env, name string, number int, keyCh chan string) error {
-	jobUrl := env.Url + "/job/" + name + "/" + strconv.Itoa(number) + "/console"
-	lastBuild, _ := jj.GetLastSuccessfulBuildInfo(env, name)
+	return watchFrom(env, name, number, "0", getTime(), keyCh)
+}
+
+// watchFrom 从日志的 cursor 位置开始跟踪构建，stime 是构建开始的时间，用于估算进度
+func watchFrom(env jj.Env, name string, number int, cursor string, stime int64, keyCh chan string) error {
+	jobUrl := strings.TrimSuffix(env.Url, "/") + "/" + jj.JobPath(name).URL() + "/" + strconv.Itoa(number) + "/console"
+	lastBuild, err := jj.GetLastSuccessfulBuildInfo(env, name)
+	if err != nil {
+		// 没有成功过的构建时无法估算进度
+		lastBuild = &jj.BuildInfo{}
+	}
 	listenerStatus = true
 	defer func() {
 		listenerStatus = false
 	}()
 	ticks := 1
-	cursor := "0"
-	stime := getTime()
 	chMsg := make(chan string)
-	closeCh = make(chan struct{})
+	// 每次跟踪使用自己的 closeCh，跟踪下游任务时上一次的 goroutine 可能还没有退出
+	closeCh := make(chan struct{})
 	finishCh := make(chan struct {
 		err    error
 		result string
@@ -385,15 +449,20 @@ func watchTheJob(env jj.Env, name string, number int, keyCh chan string) error {
 	needWatchDeployStatus := true
 	var wg sync.WaitGroup
 	wg.Add(1)
-	go barHandler(jobUrl, keyCh, chMsg, finishCh, &wg)
+	// Pipeline 任务的阶段与日志一起输出，正在运行的阶段显示在进度条上
+	var stageCh chan string
+	stages := newStageWatcher(env, name, number)
+	if interactive() {
+		stageCh = make(chan string)
+		go barHandler(jobUrl, keyCh, chMsg, stageCh, finishCh, closeCh, &wg)
+	} else {
+		go plainHandler(jobUrl, chMsg, finishCh, closeCh, &wg)
+	}
 	defer close(closeCh)
 	defer wg.Wait()
+	go stages.watch(chMsg, stageCh, closeCh)
 
-	// 添加总体超时机制
-	totalTimeout := 12 * time.Minute // 12分钟总超时
-	timeoutTimer := time.NewTimer(totalTimeout)
-	defer timeoutTimer.Stop()
-
+	// 按上次成功构建的耗时估算进度
 	go func() {
 		for {
 			select {
@@ -402,29 +471,37 @@ func watchTheJob(env jj.Env, name string, number int, keyCh chan string) error {
 				dtime := ctime - stime
 				newTicks := int(float64(dtime) / float64(lastBuild.Duration) * 100)
 				for ticks < newTicks && ticks < 99 {
-					chMsg <- ""
+					select {
+					case chMsg <- "":
+					case <-closeCh:
+						return
+					}
 					ticks++
 				}
 			case <-closeCh:
 				return
-			case <-timeoutTimer.C:
-				fmt.Printf("\n⏰ Job 监控超时 (%.0f分钟)，自动退出\n", totalTimeout.Minutes())
-				err := errors.New("timeout")
-				finishCh <- struct {
-					err    error
-					result string
-				}{err, "TIMEOUT"}
-				return
 			}
 		}
 	}()
 
-	handle := func(cursor string, sleepTime int) string {
-		output, nextCursor, err := jj.Console(env, name, number, cursor)
-		if err != nil || cursor == nextCursor {
-			return cursor
+	poll := env.Polling.Backoff()
+	// handle 返回下一段日志的位置，以及 Jenkins 是否还在写日志（X-More-Data）
+	handle := func(cursor string, sleepTime int) (string, bool) {
+		part, err := jj.GetConsolePart(env, name, number, cursor)
+		if err != nil {
+			// 只在临时错误时重试，否则构建结束后 drain 无法退出
+			return cursor, jj.IsTemporary(err)
+		}
+		nextCursor := part.Next
+		if cursor == nextCursor {
+			return cursor, part.More
+		}
+		output := stripHTMLTags(part.Text)
+		if !interactive() {
+			// 非交互模式下原样输出全部日志
+			chMsg <- strings.TrimSuffix(output, "\n")
+			return nextCursor, part.More
 		}
-		output = stripHTMLTags(output)
 		lines := strings.Split(output, "\n")
 		count := len(lines)
 		if count > 50 {
@@ -491,53 +568,58 @@ func watchTheJob(env jj.Env, name string, number int, keyCh chan string) error {
 				}
 			}
 		}
-		return nextCursor
+		return nextCursor, part.More
 	}
 
-	// 添加主循环超时检查
-	mainLoopTimeout := 10 * time.Minute // 主循环10分钟超时
-	mainLoopStart := time.Now()
+	// drain 读取剩余的全部日志，直到 Jenkins 不再返回 X-More-Data
+	drain := func() {
+		for {
+			nc, more := handle(cursor, 1)
+			if nc == cursor && !more {
+				return
+			}
+			if nc == cursor {
+				poll.Sleep()
+			} else {
+				poll.Reset()
+			}
+			cursor = nc
+		}
+	}
+
+	// 超时只在主循环中检查，等待 input 时重新计时
+	deadline := time.Now().Add(watchTimeout)
+	inputsSeen := map[string]bool{}
 
 	for {
-		// 检查主循环是否超时
-		if time.Since(mainLoopStart) > mainLoopTimeout {
-			fmt.Printf("\n⏰ Job 主循环超时 (%.0f分钟)，自动退出\n", mainLoopTimeout.Minutes())
-			err := errors.New("main loop timeout")
+		if watchTimeout > 0 && time.Now().After(deadline) {
+			fmt.Printf("\n⏰ 跟踪构建超过 %s，自动退出\n", watchTimeout)
 			finishCh <- struct {
 				err    error
 				result string
-			}{err, "TIMEOUT"}
-			return err
+			}{errTimeout, "TIMEOUT"}
+			return errTimeout
 		}
 
 		curBuild, err := jj.GetBuildInfo(env, name, number)
 		if err != nil {
-			if getTime()-stime > int64(60*time.Millisecond) {
-				err := errors.New("failed")
+			// 网络抖动或 Jenkins 暂时不可用时继续重试，其它错误直接结束
+			if jj.IsTemporary(err) {
+				poll.Sleep()
+				continue
+			}
+			if !errors.Is(err, jj.ErrNotFound) || getTime()-stime > 60*1000 {
 				finishCh <- struct {
 					err    error
 					result string
-				}{err, err.Error()}
+				}{err, "failed"}
 				return err
 			}
 		} else {
 			if !curBuild.Building {
+				drain()
+				stages.flush(chMsg)
 				if curBuild.Result == "SUCCESS" {
-					k := 0
-					for {
-						k++
-						nc := handle(cursor, 1)
-						if k > 5 {
-							fmt.Println()
-							fmt.Println("nc", nc, cursor)
-							fmt.Println()
-						}
-						if nc == cursor {
-							break
-						}
-						cursor = nc
-					}
-
 					finishCh <- struct {
 						err    error
 						result string
@@ -550,7 +632,7 @@ func watchTheJob(env jj.Env, name string, number int, keyCh chan string) error {
 					}
 					return nil
 				} else {
-					err := errors.New("failed")
+					err := &resultError{job: name, number: number, result: curBuild.Result}
 					finishCh <- struct {
 						err    error
 						result string
@@ -558,23 +640,30 @@ func watchTheJob(env jj.Env, name string, number int, keyCh chan string) error {
 					return err
 				}
 			}
+			// Pipeline 等待 input 时询问是否继续，等待的时间不计入超时
+			if stages.pausedForInput() {
+				answerInputs(env, name, number, inputsSeen, chMsg, keyCh)
+				deadline = time.Now().Add(watchTimeout)
+			}
 		}
-		ncursor := handle(cursor, 100)
-		if ncursor != cursor {
+		// 日志有输出时保持最短间隔，空闲时逐渐拉长轮询间隔
+		for {
+			ncursor, more := handle(cursor, 100)
+			if ncursor == cursor {
+				break
+			}
 			cursor = ncursor
-			//dotick()
-			ctime := getTime()
-			dtime := ctime - stime
-			if dtime < 10 {
-				time.Sleep(time.Duration(10-dtime) * time.Millisecond)
+			poll.Reset()
+			if !more {
+				break
 			}
-		} else {
-			time.Sleep(10 * time.Millisecond)
 		}
+		poll.Sleep()
 	}
 }
 
 func watchNext(env jj.Env, parentName string, childName string, parentJobID int, keyCh chan string) error {
+	poll := env.Polling.Backoff()
 	for i := 0; ; i++ {
 		bi, err := findDownstreamInBuilds(env, parentName, childName, parentJobID)
 		if err != nil {
@@ -582,10 +671,13 @@ func watchNext(env jj.Env, parentName string, childName string, parentJobID int,
 			curSt.queue = queueId
 			curSt.name = childName
 			if err != nil {
-				time.Sleep(250 * time.Millisecond)
+				poll.Sleep()
 				continue
 			}
-			number := waitForExecutor(env, queueId)
+			number, err := waitForExecutor(env, queueId)
+			if err != nil {
+				return err
+			}
 			curSt.id = number
 			return watchTheJob(env, childName, number, keyCh)
 		} else {
@@ -599,6 +691,9 @@ func watchNext(env jj.Env, parentName string, childName string, parentJobID int,
 
 func findDownstreamInBuilds(env jj.Env, parentName string, childName string, parent int) (*jj.BuildInfo, error) {
 	err, jobInfo := jj.GetJobInfo(env, childName)
+	if jj.IsTemporary(err) {
+		return &jj.BuildInfo{}, err
+	}
 	check(err)
 	number := jobInfo.LastBuild.Number
 	for i := 5; i >= 0; i-- {
@@ -618,9 +713,12 @@ func findDownstreamInBuilds(env jj.Env, parentName string, childName string, par
 }
 
 func findDownstreamInQueue(env jj.Env, parentName string, childName string, parentJobID int) (int, error) {
-	queues := jj.GetQueues(env)
+	queues, err := jj.GetQueues(env)
+	if err != nil {
+		return 0, err
+	}
 	for _, queue := range queues.Items {
-		if queue.Task.Name == childName {
+		if jj.JobPath(childName).MatchURL(queue.Task.URL) {
 			for _, action := range queue.Actions {
 				for _, cause := range action.Causes {
 					if cause.UpstreamBuild == parentJobID && cause.UpstreamProject == parentName {
@@ -659,36 +757,42 @@ func listenInterrupt(env jj.Env) {
 				barMutex.Lock()
 
 				defer barMutex.Unlock()
-				stdinListener.NewListener()
-				readline.Stdin = stdinListener
-				rl, err := readline.New(fmt.Sprintf("There is active build: %s. Do you want to cancel it [Y/n]:", curSt.name))
-				defer rl.Close()
-				if err != nil {
-					os.Exit(1)
-				}
-				line, err := rl.Readline()
-				if err != nil { // io.EOF
-					os.Exit(1)
+				// 非交互模式下无法确认，直接取消正在运行的构建
+				line := "y"
+				if interactive() {
+					stdinListener.NewListener()
+					readline.Stdin = stdinListener
+					rl, err := readline.New(fmt.Sprintf("There is active build: %s. Do you want to cancel it [Y/n]:", curSt.name))
+					defer rl.Close()
+					if err != nil {
+						os.Exit(1)
+					}
+					line, err = rl.Readline()
+					if err != nil { // io.EOF
+						os.Exit(1)
+					}
 				}
 				if line == "Y" || line == "y" {
 
 					if curSt.queue != 0 {
 						fmt.Println("canceling queue...")
-						jj.CancelQueue(env, curSt.queue)
+						if err := jj.CancelQueue(env, curSt.queue); err != nil {
+							fmt.Printf("failed to cancel queue, error %s\n", err)
+						}
 					}
 					if curSt.id != 0 {
 						fmt.Println("canceling job...")
 						status, err := jj.CancelJob(env, curSt.name, curSt.id)
 						if err != nil {
 							fmt.Printf("failed to cancel job, error %s", err)
-							os.Exit(0)
+							os.Exit(exitClientError)
 						}
 						if status != "ABORTED" {
 							fmt.Printf("Job already has been executed, status: %s", status)
-							os.Exit(0)
+							os.Exit(exitCode(&resultError{result: status}))
 						}
 						fmt.Println("Canceled")
-						os.Exit(0)
+						os.Exit(exitAborted)
 					}
 					if curSt.queue != 0 && curSt.id == 0 {
 						err, jobInfo := jj.GetJobInfo(env, curSt.name)
@@ -702,14 +806,15 @@ func listenInterrupt(env jj.Env) {
 							if bi.QueueId == curSt.queue {
 								if bi.Result != "ABORTED" {
 									fmt.Printf("Job already has been executed, status: %s", bi.Result)
-									os.Exit(0)
+									os.Exit(exitCode(&resultError{result: bi.Result}))
 								} else {
 									fmt.Println("Canceled!")
-									os.Exit(0)
+									os.Exit(exitAborted)
 								}
 							}
 						}
 						fmt.Println("Canceled!!!")
+						os.Exit(exitQueueCancelled)
 					}
 				}
 				os.Exit(0)
@@ -721,20 +826,30 @@ func listenInterrupt(env jj.Env) {
 func check(err error) {
 	if err != nil {
 		fmt.Printf("\nError: %s\n", err.Error())
-		os.Exit(1)
+		os.Exit(exitClientError)
 	}
 }
 
+func mustInit(name string) jj.Env {
+	env, err := jj.Init(name)
+	if errors.Is(err, jj.ErrNoEnv) {
+		err = fmt.Errorf("Jenkins '%s' is not found", name)
+	}
+	check(err)
+	if env.NeedsTLSMigration() {
+		fmt.Fprintf(os.Stderr, "warning: TLS certificate of '%s' is not verified, run 'jj set %s' to enable verification or pass --insecure to keep it disabled\n", env.Name, env.Name)
+	}
+	return env
+}
+
 // 查找匹配的任务
 func findMatchingJobs(env jj.Env, pattern string) []string {
 	matchedJobs := make(map[string]struct{}) // 使用 map 来去重
 	bundle := jj.GetBundle(env)
 
-	for _, view := range bundle.Views {
-		for _, job := range view.Jobs {
-			if strings.Contains(strings.ToLower(job.Name), strings.ToLower(pattern)) {
-				matchedJobs[job.Name] = struct{}{} // 使用 map 自动去重
-			}
+	for _, job := range bundle.AllJobs() {
+		if strings.Contains(strings.ToLower(job.Name), strings.ToLower(pattern)) {
+			matchedJobs[job.Name] = struct{}{} // 使用 map 自动去重
 		}
 	}
 
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj"
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj/jjtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	// 避免测试读写真实的 ~/.jj 配置
+	home, _ := ioutil.TempDir("", "jj")
+	os.Setenv("HOME", home)
+	code := m.Run()
+	os.RemoveAll(home)
+	os.Exit(code)
+}
+
+func startBuild(t *testing.T, env jj.Env, name string) int {
+	err, queueId := jj.Build(env, name, "")
+	assert.NoError(t, err)
+	id, err := strconv.Atoi(queueId)
+	assert.NoError(t, err)
+	number, err := waitForExecutor(env, id)
+	assert.NoError(t, err)
+	return number
+}
+
+func TestFindMatchingJobs(t *testing.T) {
+	srv := jjtest.New()
+	defer srv.Close()
+	srv.AddJob("app-build")
+	srv.AddJob("team/app/main")
+	srv.AddJob("web")
+	env := srv.Env()
+	assert.NoError(t, jj.GetClient(env).RefreshBundle(context.Background()))
+
+	assert.ElementsMatch(t, []string{"app-build", "team/app/main"}, findMatchingJobs(env, "app"))
+	assert.Equal(t, []string{"team/app/main"}, findMatchingJobs(env, "MAIN"))
+}
+
+func TestWatchTheJob(t *testing.T) {
+	srv := jjtest.New()
+	defer srv.Close()
+	srv.AddJob("app").Script = jjtest.Script{Log: []string{"step 1\n", "step 2\n"}, Result: "FAILURE"}
+	env := srv.Env()
+
+	number := startBuild(t, env, "app")
+	assert.Equal(t, 1, number)
+	assert.Equal(t, exitFailure, exitCode(watchTheJob(env, "app", number, nil)))
+	assert.Equal(t, "FAILURE", srv.Builds("app")[0].Result)
+}
+
+func TestWatchTheJobLogError(t *testing.T) {
+	srv := jjtest.New()
+	defer srv.Close()
+	job := srv.AddJob("app")
+	job.Script = jjtest.Script{Log: []string{"step 1\n"}, Result: "SUCCESS"}
+	job.LogStatus = 403
+	env := srv.Env()
+
+	number := startBuild(t, env, "app")
+	done := make(chan error)
+	go func() { done <- watchTheJob(env, "app", number, nil) }()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("watch doesn't stop when the log can't be read")
+	}
+}
+
+func TestWatchNext(t *testing.T) {
+	srv := jjtest.New()
+	defer srv.Close()
+	srv.AddJob("app").Downstream = []string{"team/deploy"}
+	srv.AddJob("team/deploy").Script = jjtest.Script{QueuePolls: 2, Log: []string{"deploying\n"}, Result: "ABORTED"}
+	env := srv.Env()
+
+	number := startBuild(t, env, "app")
+	assert.Equal(t, exitAborted, exitCode(watchNext(env, "app", "team/deploy", number, nil)))
+
+	builds := srv.Builds("team/deploy")
+	assert.Len(t, builds, 1)
+	assert.Equal(t, "app", builds[0].Upstream)
+	assert.Equal(t, number, builds[0].UpstreamId)
+	assert.Equal(t, "ABORTED", builds[0].Result)
+}
+
+func useServer(t *testing.T, srv *jjtest.Server) jj.Env {
+	env := srv.Env()
+	jj.SetEnv(env)
+	ENV = string(env.Name)
+	t.Cleanup(func() { ENV = "" })
+	return env
+}
+
+func TestRunJobNonInteractive(t *testing.T) {
+	srv := jjtest.New()
+	defer srv.Close()
+	branch := jj.ParameterDefinitions{Name: "BRANCH", Type: "StringParameterDefinition"}
+	branch.DefaultParameterValue.Value = "master"
+	target := jj.ParameterDefinitions{Name: "TARGET", Type: "ChoiceParameterDefinition", Choices: []string{"uat", "prod"}}
+	srv.AddJob("app", branch, target).Script = jjtest.Script{Log: []string{"building\n"}, Result: "FAILURE"}
+	useServer(t, srv)
+
+	nonInteractive = true
+	inputArgs = arguments{args: []string{"TARGET=prod"}}
+	defer func() {
+		nonInteractive = false
+		inputArgs = arguments{}
+	}()
+	assert.Equal(t, exitFailure, exitCode(runJob("app")))
+
+	builds := srv.Builds("app")
+	assert.Len(t, builds, 1)
+	assert.Equal(t, "master", builds[0].Params.Get("BRANCH"))
+	assert.Equal(t, "prod", builds[0].Params.Get("TARGET"))
+	assert.Equal(t, "FAILURE", builds[0].Result)
+}
+
+func TestRunJobKeepsClient(t *testing.T) {
+	srv := jjtest.New()
+	defer srv.Close()
+	srv.AddJob("deploy-docs").Script = jjtest.Script{Log: []string{"done\n"}, Result: "SUCCESS"}
+	env := srv.Env()
+	env.Url = srv.URL
+	jj.SetEnv(env)
+	ENV = string(env.Name)
+	defer func() { ENV = "" }()
+
+	client := jj.GetClient(env)
+	nonInteractive = true
+	defer func() { nonInteractive = false }()
+	assert.NoError(t, runJob("deploy-docs"))
+	assert.Same(t, client, jj.GetClient(env), "the client with the loaded bundle should be reused")
+}
@@ -2,6 +2,8 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
+
 	"github.com/gocruncher/jenkins-job-cli/cmd/jj"
 	"github.com/spf13/cobra"
 )
@@ -9,6 +11,15 @@ import (
 var URL string
 var login string
 var token string
+var tokenEnv string
+var tokenCommand string
+var tlsFlags struct {
+	caFile     string
+	certFile   string
+	keyFile    string
+	insecure   bool
+	serverName string
+}
 
 func init() {
 	setCmd := &cobra.Command{
@@ -19,18 +30,26 @@ where NAME is alias of your jenkins service which will be used in other commands
 Specifying a NAME that already exists will merge new fields on top of existing values for those fields.
 `,
 		Run: func(cmd *cobra.Command, args []string) {
-			set(args)
+			set(cmd, args)
 		},
 		Args: cobra.ExactArgs(1),
 	}
 	setCmd.Flags().StringVarP(&URL, "url", "u", "", "URL of the Jenkins")
 	setCmd.Flags().StringVarP(&login, "login", "l", "", "login")
-	setCmd.Flags().StringVarP(&token, "token", "t", "", "API token")
+	setCmd.Flags().StringVarP(&token, "token", "t", "", "API token, it is kept encrypted in ~/.jj/secrets")
+	setCmd.Flags().StringVar(&tokenEnv, "token-env", "", "environment variable holding the API token")
+	setCmd.Flags().StringVar(&tokenCommand, "token-command", "", "command printing the API token, e.g. 'pass show jenkins'")
+	setCmd.Flags().StringVar(&tlsFlags.caFile, "ca-file", "", "PEM bundle of certificate authorities to trust")
+	setCmd.Flags().StringVar(&tlsFlags.certFile, "cert", "", "client certificate for mutual TLS")
+	setCmd.Flags().StringVar(&tlsFlags.keyFile, "key", "", "private key of the client certificate")
+	setCmd.Flags().BoolVar(&tlsFlags.insecure, "insecure", false, "skip verification of the server certificate")
+	setCmd.Flags().StringVar(&tlsFlags.serverName, "tls-server-name", "", "server name used to verify the certificate")
 
 	rootCmd.AddCommand(setCmd)
+	jj.DefaultFileStore.Passphrase = askPassphrase
 }
 
-func set(args []string) {
+func set(cmd *cobra.Command, args []string) {
 	fmt.Println("please specify requires parameters:")
 	var name string
 	if len(args) == 0 {
@@ -64,6 +83,7 @@ func set(args []string) {
 	}
 	env.Name = jj.EName(name)
 	env.Type = jj.EType(authtype)
+	env.TLS = setTLS(cmd, env)
 	if authtype == "a" {
 		if login == "" {
 			env.Login = getBaseAnswer("login: ", env.Login)
@@ -71,10 +91,9 @@ func set(args []string) {
 			env.Login = login
 
 		}
-		if token == "" {
-			env.Secret = getBaseAnswer("token: ", env.Secret)
-		} else {
-			env.Secret = token
+		if err := setSecret(&env); err != nil {
+			fmt.Println(err.Error())
+			return
 		}
 	}
 	fmt.Println("checking...")
@@ -87,3 +106,102 @@ func set(args []string) {
 	jj.SetEnv(env)
 	fmt.Println("Added")
 }
+
+// setSecret stores a reference to the token instead of the token itself
+func setSecret(env *jj.Env) error {
+	storage := "f"
+	switch {
+	case tokenEnv != "":
+		storage = "e"
+	case tokenCommand != "":
+		storage = "c"
+	case token == "":
+		fmt.Println(`Choose where the token is kept:
+	f - encrypted file ~/.jj/secrets
+	e - environment variable
+	c - command printing the token`)
+		storage = getAnswer("token storage: ", "f", []string{"f", "e", "c"})
+	}
+	scheme, arg := "", ""
+	if parts := strings.SplitN(env.SecretRef, ":", 2); len(parts) == 2 {
+		scheme, arg = parts[0], parts[1]
+	}
+	switch storage {
+	case "e":
+		if tokenEnv == "" {
+			if scheme != "env" {
+				arg = ""
+			}
+			tokenEnv = getBaseAnswer("environment variable: ", arg)
+		}
+		env.SecretRef = jj.SecretRef("env", tokenEnv)
+	case "c":
+		if tokenCommand == "" {
+			if scheme != "command" {
+				arg = ""
+			}
+			tokenCommand = getBaseAnswer("command: ", arg)
+		}
+		env.SecretRef = jj.SecretRef("command", tokenCommand)
+	default:
+		secret := token
+		if secret == "" {
+			secret = getPassword("token: ")
+		}
+		if err := jj.DefaultFileStore.Store(string(env.Name), secret); err != nil {
+			return fmt.Errorf("failed to save the token: %w", err)
+		}
+		env.SecretRef = jj.SecretRef("file", string(env.Name))
+	}
+	// plain text secret of older versions is not kept any more
+	env.Secret = ""
+	return nil
+}
+
+// setTLS merges the tls flags into the settings of env, verification is
+// enabled unless --insecure is given or it is declined in the dialog
+func setTLS(cmd *cobra.Command, env jj.Env) *jj.TLS {
+	conf := jj.TLS{Verify: true}
+	if env.TLS != nil {
+		conf = *env.TLS
+	}
+	flags := cmd.Flags()
+	tlsChanged := false
+	for _, name := range []string{"ca-file", "cert", "key", "insecure", "tls-server-name"} {
+		tlsChanged = tlsChanged || flags.Changed(name)
+	}
+	if !tlsChanged && URL == "" && strings.HasPrefix(strings.ToLower(env.Url), "https://") {
+		def := "y"
+		if !conf.Verify {
+			def = "n"
+		}
+		conf.Verify = getAnswer("verify TLS certificate (y/n): ", def, []string{"y", "n"}) != "n"
+		if conf.Verify {
+			conf.CAFile = getOptionalAnswer("CA bundle (optional): ", conf.CAFile)
+			conf.ServerName = getOptionalAnswer("TLS server name (optional): ", conf.ServerName)
+		}
+		conf.CertFile = getOptionalAnswer("client certificate (optional): ", conf.CertFile)
+		if conf.CertFile != "" {
+			conf.KeyFile = getBaseAnswer("client key: ", conf.KeyFile)
+		} else {
+			conf.KeyFile = ""
+		}
+		return &conf
+	}
+	if flags.Changed("insecure") {
+		conf.Verify = !tlsFlags.insecure
+	}
+	if flags.Changed("ca-file") {
+		conf.CAFile = tlsFlags.caFile
+	}
+	if flags.Changed("cert") {
+		conf.CertFile = tlsFlags.certFile
+	}
+	if flags.Changed("key") {
+		conf.KeyFile = tlsFlags.keyFile
+	}
+	if flags.Changed("tls-server-name") {
+		conf.ServerName = tlsFlags.serverName
+	}
+	return &conf
+}
@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	logLines := 0
+	stagesCmd := &cobra.Command{
+		Use:   "stages JOB [BUILD|lastBuild]",
+		Short: "显示流水线构建的阶段",
+		Long: `显示 Pipeline 构建（默认为最后一次构建）每个阶段的状态、耗时和日志片段，需要 Pipeline Stage View 插件。
+失败、不稳定和进行中的阶段显示最后几行日志，-o wide 显示所有阶段的日志。并行执行的阶段以 ║ 标出。`,
+		Run: func(cmd *cobra.Command, args []string) {
+			env := mustInit(ENV)
+			job, err := selectJob(env, args[0])
+			check(err)
+			build := "lastBuild"
+			if len(args) > 1 {
+				build = args[1]
+			}
+			check(showStages(env, job, build, logLines))
+		},
+		Args: cobra.RangeArgs(1, 2),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 1 {
+				if err := checkBuild(args[1]); err != nil {
+					return err
+				}
+			}
+			return preRunE(cmd, args)
+		},
+	}
+	stagesCmd.Flags().StringVarP(&ENV, "name", "n", "", "current Jenkins name")
+	stagesCmd.Flags().IntVar(&logLines, "log-lines", 5, "last lines of the stage logs to show, 0 hides them")
+	rootCmd.AddCommand(stagesCmd)
+}
+
+func showStages(env jj.Env, job string, build string, logLines int) error {
+	number, err := buildNumber(env, job, build)
+	if err != nil {
+		return err
+	}
+	run, err := jj.DescribeRun(env, job, number)
+	if errors.Is(err, jj.ErrNotFound) {
+		return fmt.Errorf("%s 不是 Pipeline 任务，或者 Jenkins 没有安装 Pipeline Stage View 插件", job)
+	}
+	if err != nil {
+		return err
+	}
+	return printOutput(run, func(w io.Writer, wide bool) {
+		fmt.Fprintf(w, "%s %s: %s\n", job, run.Name, run.Status)
+		parallel := parallelStages(run.Stages)
+		width := 0
+		for _, st := range run.Stages {
+			if len(st.Name) > width {
+				width = len(st.Name)
+			}
+		}
+		for i, st := range run.Stages {
+			mark := "  "
+			if parallel[i] {
+				mark = "║ "
+			}
+			fmt.Fprintf(w, "%s%s %-*s  %-20s  %s\n", mark, stageIcon(st.Status), width, st.Name, st.Status, stageDuration(st))
+			if logLines == 0 || st.Status == jj.StageNotExecuted {
+				continue
+			}
+			if wide || st.Running() || st.Status == jj.StageFailed || st.Status == jj.StageUnstable {
+				if text, err := jj.GetStageLog(env, job, number, st.ID); err == nil && text != "" {
+					lines := strings.Split(stripHTMLTags(text), "\n")
+					if len(lines) > logLines {
+						lines = lines[len(lines)-logLines:]
+					}
+					fmt.Fprintln(w, indent(strings.Join(lines, "\n"), "      │ "))
+				}
+			}
+		}
+	})
+}
+
+// parallelStages 标出与前后阶段在时间上重叠的阶段，Stage View 插件把并行分支中的阶段依次列出
+func parallelStages(stages []jj.Stage) []bool {
+	parallel := make([]bool, len(stages))
+	for i := 1; i < len(stages); i++ {
+		prev, cur := stages[i-1], stages[i]
+		if cur.Status != jj.StageNotExecuted && prev.Status != jj.StageNotExecuted &&
+			cur.StartTimeMillis < prev.StartTimeMillis+prev.DurationMillis {
+			parallel[i-1], parallel[i] = true, true
+		}
+	}
+	return parallel
+}
+
+func stageIcon(status string) string {
+	switch status {
+	case jj.StageSuccess:
+		return "✓"
+	case jj.StageFailed:
+		return "✗"
+	case jj.StageUnstable:
+		return "!"
+	case jj.StageAborted:
+		return "■"
+	case jj.StageInProgress:
+		return "▶"
+	case jj.StagePaused:
+		return "⏸"
+	default:
+		return "·"
+	}
+}
+
+func stageDuration(st jj.Stage) string {
+	if st.Status == jj.StageNotExecuted {
+		return ""
+	}
+	return (time.Duration(st.DurationMillis) * time.Millisecond).Round(time.Second).String()
+}
+
+// stageWatcher 在跟踪 Pipeline 构建时检查阶段，阶段开始和结束时各输出一行
+type stageWatcher struct {
+	env    jj.Env
+	job    string
+	number int
+	mutex  sync.Mutex
+	// 已经输出过的阶段状态
+	seen map[string]string
+	// 构建在等待 input
+	paused bool
+}
+
+func newStageWatcher(env jj.Env, job string, number int) *stageWatcher {
+	return &stageWatcher{env: env, job: job, number: number, seen: map[string]string{}}
+}
+
+// poll 返回阶段状态变化的描述和正在运行的阶段，不是 Pipeline 任务时返回 ErrNotFound
+func (s *stageWatcher) poll() ([]string, []string, error) {
+	run, err := jj.DescribeRun(s.env, s.job, s.number)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.paused = run.Status == jj.StagePaused
+	parallel := parallelStages(run.Stages)
+	lines := []string{}
+	running := []string{}
+	for i, st := range run.Stages {
+		if st.Running() {
+			running = append(running, st.Name)
+		}
+		if st.Status == jj.StageNotExecuted || s.seen[st.ID] == st.Status {
+			continue
+		}
+		s.seen[st.ID] = st.Status
+		line := fmt.Sprintf("%s stage %s: %s", stageIcon(st.Status), st.Name, st.Status)
+		if parallel[i] {
+			line = "║ " + line
+		}
+		if !st.Running() {
+			line += " (" + stageDuration(st) + ")"
+		}
+		lines = append(lines, line)
+	}
+	return lines, running, nil
+}
+
+// watch 定期检查阶段直到 done 关闭，状态变化通过 chMsg 输出，
+// 正在运行的阶段发送到 stageCh（可以为 nil）
+func (s *stageWatcher) watch(chMsg chan string, stageCh chan string, done chan struct{}) {
+	poll := s.env.Polling.Backoff()
+	for {
+		lines, running, err := s.poll()
+		if err != nil && !jj.IsTemporary(err) {
+			// 不是 Pipeline 任务
+			return
+		}
+		for _, line := range lines {
+			select {
+			case chMsg <- line:
+			case <-done:
+				return
+			}
+		}
+		if err == nil && stageCh != nil {
+			select {
+			case stageCh <- strings.Join(running, ", "):
+			case <-done:
+				return
+			}
+		}
+		if len(lines) > 0 {
+			poll.Reset()
+		}
+		select {
+		case <-done:
+			return
+		default:
+		}
+		poll.Sleep()
+	}
+}
+
+func (s *stageWatcher) pausedForInput() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.paused
+}
+
+// flush 在构建结束时输出最后的阶段变化
+func (s *stageWatcher) flush(chMsg chan string) {
+	lines, _, err := s.poll()
+	if err != nil {
+		return
+	}
+	for _, line := range lines {
+		chMsg <- line
+	}
+}
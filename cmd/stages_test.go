@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj"
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj/jjtest"
+	"github.com/stretchr/testify/assert"
+)
+
+var pipelineStages = []jjtest.Stage{
+	{Name: "Build", Log: "compiling\n"},
+	{Name: "Unit", Log: "unit tests\n"},
+	{Name: "Lint", Parallel: true, Status: "FAILED", Log: "<b>3 issues</b>\nlint failed\n"},
+}
+
+func TestParallelStages(t *testing.T) {
+	stages := []jj.Stage{
+		{Status: "SUCCESS", StartTimeMillis: 1000, DurationMillis: 1000},
+		{Status: "SUCCESS", StartTimeMillis: 2000, DurationMillis: 500},
+		{Status: "FAILED", StartTimeMillis: 2100, DurationMillis: 900},
+		{Status: "SUCCESS", StartTimeMillis: 3000, DurationMillis: 100},
+		{Status: "NOT_EXECUTED"},
+	}
+	assert.Equal(t, []bool{false, true, true, false, false}, parallelStages(stages))
+}
+
+func TestShowStages(t *testing.T) {
+	srv := jjtest.New()
+	defer srv.Close()
+	job := srv.AddJob("pipeline")
+	job.Script = jjtest.Script{Log: []string{"a\n", "b\n"}, Result: "FAILURE"}
+	job.Stages = pipelineStages
+	env := useServer(t, srv)
+	startBuild(t, env, "pipeline")
+	srv.Finish("pipeline", 1, "FAILURE")
+
+	var err error
+	out := captureStdout(t, func() { err = showStages(env, "pipeline", "1", 1) })
+	assert.NoError(t, err)
+	assert.Equal(t, `pipeline #1: FAILED
+  ✓ Build  SUCCESS               1s
+║ ✓ Unit   SUCCESS               1s
+║ ✗ Lint   FAILED                1s
+      │ lint failed
+`, out)
+}
+
+func TestRunStages(t *testing.T) {
+	srv := jjtest.New()
+	defer srv.Close()
+	job := srv.AddJob("pipeline")
+	job.Script = jjtest.Script{Log: []string{"compiling\n", "testing\n"}, Result: "FAILURE"}
+	job.Stages = pipelineStages
+	useServer(t, srv)
+
+	nonInteractive = true
+	defer func() { nonInteractive = false }()
+	var err error
+	out := captureStdout(t, func() { err = runJob("pipeline") })
+	assert.Equal(t, exitFailure, exitCode(err))
+	assert.Contains(t, out, "✓ stage Build: SUCCESS (1s)\n")
+	assert.Contains(t, out, "║ ✗ stage Lint: FAILED (1s)\n")
+}
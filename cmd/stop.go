@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	var wait time.Duration
+	stopCmd := &cobra.Command{
+		Use:   "stop JOB [BUILD]",
+		Short: "停止正在运行或排队中的构建",
+		Long: `停止指定任务正在运行或排队中的构建。
+如果不指定构建号，则停止最新的正在运行的构建，并取消该任务在队列中的所有构建。
+如果 stop 在等待时间内没有生效，会依次尝试 term 和 kill。`,
+		Run: func(cmd *cobra.Command, args []string) {
+			stopJob(args, wait)
+		},
+		Args:    cobra.RangeArgs(1, 2),
+		PreRunE: preRunE,
+	}
+	stopCmd.Flags().StringVarP(&ENV, "name", "n", "", "current Jenkins name")
+	stopCmd.Flags().DurationVarP(&wait, "wait", "w", 10*time.Second, "每一步等待构建停止的时间")
+	rootCmd.AddCommand(stopCmd)
+}
+
+func stopJob(args []string, wait time.Duration) {
+	env := mustInit(ENV)
+	name, err := selectJob(env, args[0])
+	check(err)
+
+	if len(args) > 1 {
+		number, err := strconv.Atoi(args[1])
+		if err != nil {
+			check(fmt.Errorf("无效的构建号: %s", args[1]))
+		}
+		check(stopBuild(env, name, number, wait))
+		return
+	}
+
+	canceled := cancelJobQueue(env, name)
+	number, err := findRunningBuild(env, name)
+	if err != nil {
+		if canceled == 0 {
+			fmt.Printf("任务 %s 没有正在运行或排队中的构建\n", name)
+		}
+		return
+	}
+	check(stopBuild(env, name, number, wait))
+}
+
+// 取消队列中属于该任务的所有构建
+func cancelJobQueue(env jj.Env, name string) int {
+	canceled := 0
+	queues, err := jj.GetQueues(env)
+	check(err)
+	for _, item := range queues.Items {
+		if !jj.JobPath(name).MatchURL(item.Task.URL) {
+			continue
+		}
+		fmt.Printf("取消排队中的构建, queue id: %d\n", item.ID)
+		check(jj.CancelQueue(env, item.ID))
+		canceled++
+	}
+	return canceled
+}
+
+// 从最后一次构建往前查找最新的正在运行的构建
+func findRunningBuild(env jj.Env, name string) (int, error) {
+	last, err := jj.GetLastBuildInfo(env, name)
+	if err != nil {
+		return 0, err
+	}
+	number, _ := strconv.Atoi(last.Id)
+	for i := number; i > 0 && i > number-10; i-- {
+		bi, err := jj.GetBuildInfo(env, name, i)
+		if err != nil {
+			continue
+		}
+		if bi.Building {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("no running build")
+}
+
+// 依次通过 stop, term, kill 停止构建，直到构建真正结束
+func stopBuild(env jj.Env, name string, number int, wait time.Duration) error {
+	actions := []struct {
+		name string
+		fn   func(jj.Env, string, int) (string, error)
+	}{
+		{"stop", jj.CancelJob},
+		{"term", jj.TermJob},
+		{"kill", jj.KillJob},
+	}
+	for _, action := range actions {
+		fmt.Printf("%s #%d (%s)...\n", name, number, action.name)
+		if _, err := action.fn(env, name, number); err != nil {
+			return err
+		}
+		deadline := time.Now().Add(wait)
+		for time.Now().Before(deadline) {
+			bi, err := jj.GetBuildInfo(env, name, number)
+			if err == nil && !bi.Building {
+				fmt.Printf("构建 #%d 已停止, 状态: %s\n", number, bi.Result)
+				return nil
+			}
+			time.Sleep(500 * time.Millisecond)
+		}
+	}
+	return fmt.Errorf("构建 #%d 仍在运行", number)
+}
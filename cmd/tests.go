@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj"
+	"github.com/spf13/cobra"
+)
+
+// run 结束后最多列出的失败用例数
+const maxSummaryCases = 5
+
+func init() {
+	stackLines := 0
+	testsCmd := &cobra.Command{
+		Use:   "tests JOB [BUILD|lastBuild]",
+		Short: "显示构建的测试报告",
+		Long: `显示构建（默认为最后一次构建）的 JUnit 测试报告：用例总数，失败的用例及其错误信息、调用栈，
+以及用例已经连续失败的构建数。-o wide 同时列出跳过的用例。`,
+		Run: func(cmd *cobra.Command, args []string) {
+			env := mustInit(ENV)
+			job, err := selectJob(env, args[0])
+			check(err)
+			build := "lastBuild"
+			if len(args) > 1 {
+				build = args[1]
+			}
+			check(showTests(env, job, build, stackLines))
+		},
+		Args: cobra.RangeArgs(1, 2),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 1 {
+				if err := checkBuild(args[1]); err != nil {
+					return err
+				}
+			}
+			return preRunE(cmd, args)
+		},
+	}
+	testsCmd.Flags().StringVarP(&ENV, "name", "n", "", "current Jenkins name")
+	testsCmd.Flags().IntVar(&stackLines, "stack-lines", 10, "lines of stack traces to show, 0 hides them, -1 shows them completely")
+	rootCmd.AddCommand(testsCmd)
+}
+
+func showTests(env jj.Env, job string, build string, stackLines int) error {
+	number, err := buildNumber(env, job, build)
+	if err != nil {
+		return err
+	}
+	report, err := jj.GetTestReport(env, job, number)
+	if errors.Is(err, jj.ErrNotFound) {
+		return fmt.Errorf("构建 %s #%d 没有测试报告", job, number)
+	}
+	if err != nil {
+		return err
+	}
+	return printOutput(report, func(w io.Writer, wide bool) {
+		writeTestReport(w, report, stackLines, wide)
+	})
+}
+
+func writeTestReport(w io.Writer, report *jj.TestReport, stackLines int, wide bool) {
+	fmt.Fprintf(w, "Tests: %d total, %d failed, %d skipped, %d passed (%.1fs)\n",
+		report.Total(), report.FailCount, report.SkipCount, report.PassCount, report.Duration)
+	for _, tc := range report.FailedCases() {
+		fmt.Fprintf(w, "\n✗ %s %s\n", tc.FullName(), failureAge(tc))
+		if tc.ErrorDetails != "" {
+			fmt.Fprintln(w, indent(tc.ErrorDetails, "    "))
+		}
+		if trace := strings.TrimRight(tc.ErrorStackTrace, "\n"); trace != "" && stackLines != 0 {
+			lines := strings.Split(trace, "\n")
+			if stackLines > 0 && len(lines) > stackLines {
+				lines = append(lines[:stackLines], fmt.Sprintf("... %d more lines", len(lines)-stackLines))
+			}
+			fmt.Fprintln(w, indent(strings.Join(lines, "\n"), "    "))
+		}
+	}
+	if !wide {
+		return
+	}
+	for _, s := range report.Suites {
+		for _, tc := range s.Cases {
+			if tc.Status == "SKIPPED" {
+				fmt.Fprintf(w, "\n- %s skipped\n", tc.FullName())
+			}
+		}
+	}
+}
+
+func failureAge(tc jj.TestCase) string {
+	if tc.Age <= 1 {
+		return "(本次构建新增的失败)"
+	}
+	return fmt.Sprintf("(已连续失败 %d 次, 从 #%d 开始)", tc.Age, tc.FailedSince)
+}
+
+func indent(text, prefix string) string {
+	return prefix + strings.ReplaceAll(strings.TrimRight(text, "\n"), "\n", "\n"+prefix)
+}
+
+// printTestSummary 在构建结果为 UNSTABLE 或 FAILURE 时列出失败的用例，
+// 构建没有测试报告时不输出
+func printTestSummary(env jj.Env, err error) {
+	var re *resultError
+	if !errors.As(err, &re) || re.job == "" || (re.result != "UNSTABLE" && re.result != "FAILURE") {
+		return
+	}
+	report, err := jj.GetTestReport(env, re.job, re.number)
+	if err != nil || report.FailCount == 0 {
+		return
+	}
+	fmt.Printf("\nTests: %d of %d failed\n", report.FailCount, report.Total())
+	failed := report.FailedCases()
+	for i, tc := range failed {
+		if i == maxSummaryCases {
+			fmt.Printf("  ... %d more, see jj tests %s %d\n", len(failed)-maxSummaryCases, re.job, re.number)
+			break
+		}
+		if details := strings.SplitN(strings.TrimSpace(tc.ErrorDetails), "\n", 2)[0]; details != "" {
+			fmt.Printf("  ✗ %s: %s\n", tc.FullName(), details)
+		} else {
+			fmt.Printf("  ✗ %s\n", tc.FullName())
+		}
+	}
+}
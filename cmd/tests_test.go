@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj"
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj/jjtest"
+	"github.com/stretchr/testify/assert"
+)
+
+var testReport = &jj.TestReport{FailCount: 2, PassCount: 1, SkipCount: 1, Duration: 2.5, Suites: []jj.TestSuite{{
+	Name: "unit",
+	Cases: []jj.TestCase{
+		{ClassName: "app.UserTest", Name: "login", Status: "PASSED"},
+		{ClassName: "app.UserTest", Name: "logout", Status: "REGRESSION", Age: 1,
+			ErrorDetails: "expected 1\nbut was 2", ErrorStackTrace: "at a\nat b\nat c\n"},
+		{ClassName: "app.ApiTest", Name: "get", Status: "FAILED", Age: 3, FailedSince: 7},
+		{ClassName: "app.ApiTest", Name: "put", Status: "SKIPPED"},
+	},
+}}}
+
+func captureStdout(t *testing.T, fn func()) string {
+	r, w, err := os.Pipe()
+	assert.NoError(t, err)
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	fn()
+	w.Close()
+	out, err := ioutil.ReadAll(r)
+	assert.NoError(t, err)
+	return string(out)
+}
+
+func TestWriteTestReport(t *testing.T) {
+	var buf bytes.Buffer
+	writeTestReport(&buf, testReport, 2, false)
+	assert.Equal(t, `Tests: 4 total, 2 failed, 1 skipped, 1 passed (2.5s)
+
+✗ app.UserTest.logout (本次构建新增的失败)
+    expected 1
+    but was 2
+    at a
+    at b
+    ... 1 more lines
+
+✗ app.ApiTest.get (已连续失败 3 次, 从 #7 开始)
+`, buf.String())
+
+	buf.Reset()
+	writeTestReport(&buf, testReport, 0, true)
+	assert.Contains(t, buf.String(), "- app.ApiTest.put skipped")
+	assert.NotContains(t, buf.String(), "at a")
+}
+
+func TestRunTestSummary(t *testing.T) {
+	srv := jjtest.New()
+	defer srv.Close()
+	job := srv.AddJob("unit-tests")
+	job.Script = jjtest.Script{Result: "UNSTABLE"}
+	job.TestReport = testReport
+	useServer(t, srv)
+
+	nonInteractive = true
+	defer func() { nonInteractive = false }()
+	var err error
+	out := captureStdout(t, func() { err = runJob("unit-tests") })
+	assert.Equal(t, exitUnstable, exitCode(err))
+	assert.Contains(t, out, "Tests: 2 of 4 failed\n  ✗ app.UserTest.logout: expected 1\n  ✗ app.ApiTest.get\n")
+}
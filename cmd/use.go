@@ -22,7 +22,7 @@ func init() {
 }
 
 func use(cmd *cobra.Command) {
-	jj.Init(cmd.Flags().Args()[0])
+	mustInit(cmd.Flags().Args()[0])
 	jj.SetDef(cmd.Flags().Args()[0])
 	fmt.Println(cmd.Flags().Args()[0] + " have been set by default")
 }
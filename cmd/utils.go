@@ -1,12 +1,33 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"github.com/chzyer/readline"
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj"
 	"os"
+	"strconv"
 	"strings"
 )
 
+var nonInteractive bool
+
+// errNonInteractive is returned instead of asking the user
+var errNonInteractive = errors.New("input is required, but jj runs non-interactively. Pass it with flags or run jj in a terminal")
+
+// interactive reports whether jj may ask the user, it is off with --yes
+// or when stdin is not a terminal
+func interactive() bool {
+	return !nonInteractive && readline.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// mustBeInteractive fails instead of asking the question in non-interactive mode
+func mustBeInteractive(question string) {
+	if !interactive() {
+		check(fmt.Errorf("%s: %w", strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(question), ":")), errNonInteractive))
+	}
+}
+
 func filterInput(r rune) (rune, bool) {
 	switch r {
 	// block CtrlZ feature
@@ -18,6 +39,55 @@ func filterInput(r rune) (rune, bool) {
 func getBaseAnswer(question string, defAnswer string) string {
 	return getAnswer(question, defAnswer, []string{})
 }
+
+// getPassword reads a line without echoing it
+func getPassword(question string) string {
+	mustBeInteractive(question)
+	for {
+		line, err := readline.Password(question)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(0)
+		}
+		if answer := strings.TrimSpace(string(line)); answer != "" {
+			return answer
+		}
+	}
+}
+
+// askPassphrase unlocks ~/.jj/secrets, $JJ_PASSPHRASE is used when it is set
+func askPassphrase(create bool) (string, error) {
+	if passphrase, err := jj.PassphraseFromEnv(create); err == nil {
+		return passphrase, nil
+	}
+	if !interactive() {
+		return "", errors.New("$JJ_PASSPHRASE is required to unlock ~/.jj/secrets")
+	}
+	if !create {
+		return getPassword("passphrase of ~/.jj/secrets: "), nil
+	}
+	passphrase := getPassword("new passphrase of ~/.jj/secrets: ")
+	if getPassword("repeat passphrase: ") != passphrase {
+		return "", errors.New("passphrases do not match")
+	}
+	return passphrase, nil
+}
+
+// getOptionalAnswer is like getBaseAnswer but accepts an empty answer
+func getOptionalAnswer(question string, defAnswer string) string {
+	mustBeInteractive(question)
+	rl, err := NewReadLine(question, []string{})
+	if err != nil {
+		panic(err)
+	}
+	defer rl.Close()
+	line, err := rl.ReadlineWithDefault(defAnswer)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(0)
+	}
+	return strings.TrimSpace(line)
+}
 func NewReadLine(question string, choices []string) (*readline.Instance, error) {
 	completer := []readline.PrefixCompleterInterface{}
 	for _, choice := range choices {
@@ -35,6 +105,7 @@ func NewReadLine(question string, choices []string) (*readline.Instance, error)
 	return rl, err
 }
 func getAnswer(question string, defAnswer string, choices []string) string {
+	mustBeInteractive(question)
 
 	for {
 		rl, err := NewReadLine(question, choices)
@@ -83,3 +154,50 @@ func findBestChoices(val string, choices []string) []string {
 	}
 	return rsp
 }
+
+// 根据名称模糊匹配任务，多个匹配项时让用户选择
+func selectJob(env jj.Env, pattern string) (string, error) {
+	jobs := findMatchingJobs(env, pattern)
+	if len(jobs) == 0 {
+		if err := jj.RefreshBundle(env); err != nil {
+			return "", err
+		}
+		jobs = findMatchingJobs(env, pattern)
+	}
+	if len(jobs) == 0 && strings.Contains(pattern, "/") {
+		return pattern, nil
+	}
+	if len(jobs) == 0 {
+		return "", fmt.Errorf("未找到匹配的任务: %s", pattern)
+	}
+	for _, job := range jobs {
+		if job == pattern {
+			return job, nil
+		}
+	}
+	if len(jobs) == 1 {
+		return jobs[0], nil
+	}
+
+	if !interactive() {
+		return "", fmt.Errorf("'%s' 匹配到多个任务: %s, %w", pattern, strings.Join(jobs, ", "), errNonInteractive)
+	}
+	fmt.Printf("\n找到 %d 个匹配的任务:\n", len(jobs))
+	for i, job := range jobs {
+		fmt.Printf("%d. %s\n", i+1, job)
+	}
+	rl, err := readline.New("请选择任务编号: ")
+	if err != nil {
+		return "", fmt.Errorf("读取输入失败: %v", err)
+	}
+	defer rl.Close()
+	line, err := rl.Readline()
+	if err != nil {
+		return "", fmt.Errorf("读取输入失败: %v", err)
+	}
+	index, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil || index < 1 || index > len(jobs) {
+		return "", errors.New("无效的选择")
+	}
+	return jobs[index-1], nil
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gocruncher/bar"
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj"
+	"github.com/spf13/cobra"
+	"github.com/ttacon/chalk"
+)
+
+// 连接到构建时显示的已有日志行数
+const attachContextLines = 10
+
+func init() {
+	// 已经在运行的构建可能还要很久，默认一直跟踪到结束
+	timeout := time.Duration(0)
+	watchCmd := &cobra.Command{
+		Use:     "watch JOB [BUILD|lastBuild]",
+		Aliases: []string{"w"},
+		Short:   "跟踪已经在运行的构建",
+		Long: `从当前的日志位置开始跟踪已经在运行的构建（默认为最后一次构建），并跟踪它的下游任务。
+Ctrl+C 只会停止跟踪，不会取消构建。
+退出码与 run 命令相同。`,
+		Run: func(cmd *cobra.Command, args []string) {
+			env := mustInit(ENV)
+			job, err := selectJob(env, args[0])
+			check(err)
+			build := "lastBuild"
+			if len(args) > 1 {
+				build = args[1]
+			}
+			watchTimeout = timeout
+			exit(attachJob(env, job, build))
+		},
+		Args: cobra.RangeArgs(1, 2),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 1 && args[1] != "lastBuild" {
+				if _, err := strconv.Atoi(args[1]); err != nil {
+					return fmt.Errorf("无效的构建号: %s", args[1])
+				}
+			}
+			return preRunE(cmd, args)
+		},
+	}
+	watchCmd.Flags().StringVarP(&ENV, "name", "n", "", "current Jenkins name")
+	watchCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "显示详细的构建输出")
+	watchCmd.Flags().DurationVar(&timeout, "timeout", 0, "stop watching a build running longer, 0 watches until it ends")
+	watchCmd.SetUsageTemplate(usageTamplate)
+	rootCmd.AddCommand(watchCmd)
+}
+
+// attachJob 连接到已有的构建，跟踪它和它的下游任务，返回的错误决定 jj 的退出码
+func attachJob(env jj.Env, name string, build string) error {
+	var bi *jj.BuildInfo
+	var err error
+	if build == "lastBuild" {
+		bi, err = jj.GetLastBuildInfo(env, name)
+	} else {
+		number, _ := strconv.Atoi(build)
+		bi, err = jj.GetBuildInfo(env, name, number)
+	}
+	if errors.Is(err, jj.ErrNotFound) {
+		err = fmt.Errorf("任务 %s 的构建 %s 不存在", name, build)
+	}
+	if err != nil {
+		return err
+	}
+	err, jobInfo := jj.GetJobInfo(env, name)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Watching %s #%d in the %s environment\n", name, bi.Number, chalk.Underline.TextStyle(string(env.Name)))
+
+	// 跳过已经输出的日志，只显示最后几行
+	part, err := jj.GetConsolePart(env, name, bi.Number, "0")
+	if err != nil {
+		return err
+	}
+	lines := strings.Split(strings.TrimSuffix(stripHTMLTags(part.Text), "\n"), "\n")
+	if len(lines) > attachContextLines {
+		lines = lines[len(lines)-attachContextLines:]
+	}
+	if part.Text != "" {
+		fmt.Println(strings.Join(lines, "\n"))
+	}
+
+	var keyCh chan string
+	if interactive() {
+		bar.InitTerminal()
+		keyCh = make(chan string)
+		stdinListener = NewStdin()
+		go listenKeys(keyCh)
+	}
+	if err := watchFrom(env, name, bi.Number, part.Next, bi.Timestamp, keyCh); err != nil {
+		printTestSummary(env, err)
+		return err
+	}
+	if err := watchDownstream(env, name, bi.Number, jobInfo, keyCh); err != nil {
+		printTestSummary(env, err)
+		return err
+	}
+	fmt.Println(chalk.Green.Color("done"))
+	return nil
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gocruncher/jenkins-job-cli/cmd/jj/jjtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttachJob(t *testing.T) {
+	srv := jjtest.New()
+	defer srv.Close()
+	app := srv.AddJob("app")
+	app.Script = jjtest.Script{Log: []string{"step 1\n", "step 2\n", "step 3\n"}}
+	app.Downstream = []string{"deploy"}
+	srv.AddJob("deploy").Script = jjtest.Script{Log: []string{"deploying\n"}, Result: "FAILURE"}
+	env := srv.Env()
+
+	nonInteractive = true
+	defer func() { nonInteractive = false }()
+
+	number := startBuild(t, env, "app")
+	// the result of the downstream build is the result of the watch
+	assert.Equal(t, exitFailure, exitCode(attachJob(env, "app", "lastBuild")))
+	assert.Equal(t, "SUCCESS", srv.Builds("app")[0].Result)
+	assert.Equal(t, number, srv.Builds("deploy")[0].UpstreamId)
+
+	assert.Equal(t, exitFailure, exitCode(attachJob(env, "deploy", "1")))
+	assert.Equal(t, exitClientError, exitCode(attachJob(env, "deploy", "2")))
+}
+
+func TestWatchTimeout(t *testing.T) {
+	srv := jjtest.New()
+	defer srv.Close()
+	srv.AddJob("long-deploy").Script = jjtest.Script{Log: []string{"deploying\n"}, Endless: true}
+	env := srv.Env()
+
+	nonInteractive = true
+	watchTimeout = 50 * time.Millisecond
+	defer func() {
+		nonInteractive = false
+		watchTimeout = 10 * time.Minute
+	}()
+	startBuild(t, env, "long-deploy")
+	assert.Equal(t, exitTimeout, exitCode(attachJob(env, "long-deploy", "lastBuild")))
+	assert.True(t, srv.Builds("long-deploy")[0].Building)
+}